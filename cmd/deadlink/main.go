@@ -0,0 +1,502 @@
+// Command deadlink scans a site for broken links from the command line,
+// without the server, a database or a login. It is meant for CI pipelines:
+//
+//	deadlink -report junit=deadlinks.xml -report sarif=deadlinks.sarif https://example.com
+//
+// Scan settings are read from .deadlink.yml in the working directory, or the
+// file named by -config. Its start_urls are scanned when no URL is given.
+//
+// With -dir, the built site or Markdown documentation in a directory is
+// checked offline instead: links must resolve to files and anchors to ids or
+// headings, and URL, if given, is where the site is published. External links are only checked with -external.
+//
+// With -list, the URLs in a file (one per line or CSV, "-" for stdin) are
+// checked once each without crawling.
+//
+// With -redirects, a CSV redirect map of old and expected new URLs is
+// verified: each old URL must reach its new URL through at most -max-hops
+// redirects. Temporary redirects are reported as warnings.
+//
+// With -baseline, known broken links listed in the file are accepted and
+// only new ones fail the run; -update-baseline rewrites the file from the
+// scan instead.
+//
+// The exit status is 0 when no broken links were found, 1 when there were
+// and 2 when the scan could not run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-deadlink-scanner/internal/config"
+	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/internal/siteconfig"
+	"go-deadlink-scanner/internal/store"
+	"go-deadlink-scanner/pkg/deadlink"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	exitOK     = 0
+	exitBroken = 1
+	exitError  = 2
+)
+
+// listFlag collects a flag that may be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ", ") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type options struct {
+	workers      int
+	certWarnDays int
+	slow         time.Duration
+	slowTTFB     time.Duration
+	output       string
+	all          bool
+	verbose      bool
+	configFile   string
+	dir          string
+	external     bool
+	list         string
+	redirects    string
+	maxHops      int
+	reports      listFlag
+	baseline     string
+	update       bool
+
+	headers      listFlag
+	cookies      listFlag
+	basicAuth    string
+	bearer       string
+	loginURL     string
+	loginFields  listFlag
+	loginSuccess string
+
+	proxy         string
+	noProxy       string
+	caFiles       listFlag
+	clientCert    string
+	clientKey     string
+	dnsServer     string
+	hostOverrides listFlag
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var o options
+	fs := flag.NewFlagSet("deadlink", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: deadlink [flags] [URL]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Crawls URL, or the start_urls of "+siteconfig.DefaultFile+", and checks every link. Exits 1 when broken links are found.")
+		fmt.Fprintln(stderr, "With -list, checks the URLs in a file without crawling.")
+		fmt.Fprintln(stderr, "With -redirects, verifies a CSV map of old and expected new URLs.")
+		fmt.Fprintln(stderr, "With -dir, checks the HTML and Markdown files in a directory instead; URL is then the address the site is published at.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	fs.IntVar(&o.workers, "workers", 10, "number of concurrent checks")
+	fs.IntVar(&o.certWarnDays, "cert-warn-days", 14, "warn about certificates expiring within this many days")
+	fs.DurationVar(&o.slow, "slow", 3*time.Second, "warn about links slower than this")
+	fs.DurationVar(&o.slowTTFB, "slow-ttfb", 1500*time.Millisecond, "warn about links with a slower time to first byte")
+	fs.StringVar(&o.output, "format", "text", "terminal output: text, "+formatList())
+	fs.BoolVar(&o.all, "all", false, "list every link in text output, not only broken ones")
+	fs.BoolVar(&o.verbose, "v", false, "log every request")
+	fs.StringVar(&o.configFile, "config", "", "read scan settings from this `file` (default "+siteconfig.DefaultFile+" when present)")
+	fs.StringVar(&o.dir, "dir", "", "check the HTML and Markdown files in this `directory` offline instead of crawling")
+	fs.BoolVar(&o.external, "external", false, "with -dir, also check external links over HTTP")
+	fs.StringVar(&o.list, "list", "", "check the URLs in this `file`, one per line or CSV, without crawling (- for stdin)")
+	fs.StringVar(&o.redirects, "redirects", "", "verify the redirect map in this CSV `file` of old and new URLs (- for stdin)")
+	fs.IntVar(&o.maxHops, "max-hops", scanner.DefaultMaxHops, "with -redirects, the most redirects an old URL may take")
+	fs.Var(&o.reports, "report", "write a report file as `format=path`; repeatable")
+	fs.StringVar(&o.baseline, "baseline", "", "only fail on broken links missing from this baseline `file`")
+	fs.BoolVar(&o.update, "update-baseline", false, "write the scan's broken links to the -baseline file instead of checking")
+
+	fs.Var(&o.headers, "header", "send `\"Name: value\"` with requests to the site; repeatable")
+	fs.Var(&o.cookies, "cookie", "send `name=value` as a cookie to the site; repeatable")
+	fs.StringVar(&o.basicAuth, "basic-auth", "", "`user:password` for HTTP basic auth (default $DEADLINK_BASIC_AUTH)")
+	fs.StringVar(&o.bearer, "bearer", "", "bearer `token` (default $DEADLINK_BEARER_TOKEN)")
+	fs.StringVar(&o.loginURL, "login-url", "", "log in by posting a form to this URL before scanning")
+	fs.Var(&o.loginFields, "login-field", "login form field as `name=value`; repeatable")
+	fs.StringVar(&o.loginSuccess, "login-success", "", "text the page after a successful login contains")
+
+	fs.StringVar(&o.proxy, "proxy", "", "proxy URL for all requests")
+	fs.StringVar(&o.noProxy, "no-proxy", "", "comma separated hosts to reach without the proxy")
+	fs.Var(&o.caFiles, "ca-file", "extra CA bundle `file` to trust; repeatable")
+	fs.StringVar(&o.clientCert, "client-cert", "", "client certificate `file` for mutual TLS")
+	fs.StringVar(&o.clientKey, "client-key", "", "client key `file` for mutual TLS")
+	fs.StringVar(&o.dnsServer, "dns-server", "", "resolve names with this `host:port` nameserver")
+	fs.Var(&o.hostOverrides, "resolve", "connect to `host=ip` instead of resolving host; repeatable")
+
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	// Secrets are best passed through the environment, where they do not
+	// show up in process listings or CI logs.
+	if o.basicAuth == "" {
+		o.basicAuth = os.Getenv("DEADLINK_BASIC_AUTH")
+	}
+	if o.bearer == "" {
+		o.bearer = os.Getenv("DEADLINK_BEARER_TOKEN")
+	}
+
+	if !o.verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	reports, err := parseReports(o.reports)
+	if err != nil {
+		fmt.Fprintln(stderr, "deadlink:", err)
+		return exitError
+	}
+	if o.output != "text" {
+		if _, ok := scanner.ParseExportFormat(o.output); !ok {
+			fmt.Fprintf(stderr, "deadlink: unknown format %q\n", o.output)
+			return exitError
+		}
+	}
+
+	if countSet(o.list, o.dir, o.redirects) > 1 {
+		fmt.Fprintln(stderr, "deadlink: only one of -list, -dir and -redirects can be used")
+		return exitError
+	}
+	if o.external && o.dir == "" {
+		fmt.Fprintln(stderr, "deadlink: -external needs -dir")
+		return exitError
+	}
+	if o.update && o.baseline == "" {
+		fmt.Fprintln(stderr, "deadlink: -update-baseline needs -baseline")
+		return exitError
+	}
+	var baseline *scanner.Baseline
+	if o.baseline != "" && !o.update {
+		if baseline, err = readBaseline(o.baseline); err != nil {
+			fmt.Fprintln(stderr, "deadlink:", err)
+			return exitError
+		}
+	}
+
+	siteCfg, err := o.siteConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, "deadlink:", err)
+		return exitError
+	}
+	var startURLs []string
+	switch {
+	case fs.NArg() == 1:
+		startURLs = []string{fs.Arg(0)}
+	case siteCfg != nil && len(siteCfg.StartURLs) > 0:
+		startURLs = siteCfg.StartURLs
+	case o.dir != "", o.list != "", o.redirects != "":
+	default:
+		fs.Usage()
+		return exitError
+	}
+
+	scanOpts, err := o.scanOptions(siteCfg)
+	if err != nil {
+		fmt.Fprintln(stderr, "deadlink:", err)
+		return exitError
+	}
+
+	service, err := scanner.NewService(store.NewMemory(0), nil, o.config())
+	if err != nil {
+		fmt.Fprintln(stderr, "deadlink:", err)
+		return exitError
+	}
+
+	started := time.Now()
+	scanned, err := o.scan(service, startURLs, scanOpts, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "deadlink:", err)
+		return exitError
+	}
+	scan := newRun(scanned, started, time.Now())
+
+	if o.update {
+		if err := writeBaseline(o.baseline, scanner.NewBaseline(scan.scan.StartUrl, scan.results, time.Now())); err != nil {
+			fmt.Fprintln(stderr, "deadlink:", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "Wrote %d broken links to %s\n", scan.broken(), o.baseline)
+		return exitOK
+	}
+	if baseline != nil {
+		scan.checkBaseline(baseline)
+	}
+
+	if err := scan.write(stdout, o.output, o.all); err != nil {
+		fmt.Fprintln(stderr, "deadlink:", err)
+		return exitError
+	}
+	for _, r := range reports {
+		if err := scan.writeFile(r.format, r.path); err != nil {
+			fmt.Fprintln(stderr, "deadlink:", err)
+			return exitError
+		}
+	}
+
+	if scan.failing() > 0 {
+		return exitBroken
+	}
+	return exitOK
+}
+
+// scan crawls the start URLs, checks the -list URLs or the -redirects map,
+// or checks the -dir directory published at the first start URL.
+func (o *options) scan(service *scanner.Service, startURLs []string, opts scanner.ScanOptions, stdin io.Reader) ([]*scanner.Report, error) {
+	if o.list != "" {
+		name, r, err := openInput(o.list, stdin)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		urls, err := scanner.ParseURLList(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		report, err := service.CheckList(name, urls, 0, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*scanner.Report{report}, nil
+	}
+	if o.redirects != "" {
+		name, r, err := openInput(o.redirects, stdin)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		redirects, err := scanner.ParseRedirectMap(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		report, err := service.VerifyRedirects(name, redirects, o.maxHops, 0, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*scanner.Report{report}, nil
+	}
+	if o.dir != "" {
+		local := scanner.LocalOptions{CheckExternal: o.external}
+		if len(startURLs) > 0 {
+			local.BaseURL = startURLs[0]
+		}
+		report, err := service.ScanDir(o.dir, local, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*scanner.Report{report}, nil
+	}
+
+	// A plain crawl is not recorded, so it runs the crawler directly.
+	var reports []*scanner.Report
+	for _, startURL := range startURLs {
+		crawl, err := service.NewCrawl(startURL, opts)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := deadlink.Crawl(context.Background(), startURL, crawl.Options); err != nil {
+			return nil, err
+		}
+		reports = append(reports, crawl.Report())
+	}
+	return reports, nil
+}
+
+// config builds the scanner configuration from flags instead of the
+// environment. The shared link cache is of no use within a single run.
+func (o *options) config() *config.Config {
+	var noProxy []string
+	for _, host := range strings.Split(o.noProxy, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			noProxy = append(noProxy, host)
+		}
+	}
+
+	return &config.Config{
+		MaxScannerWorkers:  o.workers,
+		CertExpiryWarnDays: o.certWarnDays,
+		SlowLinkThreshold:  o.slow,
+		SlowTTFBThreshold:  o.slowTTFB,
+		ProxyURL:           o.proxy,
+		NoProxy:            noProxy,
+		CAFiles:            o.caFiles,
+		ClientCertFile:     o.clientCert,
+		ClientKeyFile:      o.clientKey,
+		DNSServer:          o.dnsServer,
+		HostOverrides:      o.hostOverrides,
+	}
+}
+
+// siteConfig loads the -config file, or the default one when it exists.
+// It returns nil when there is none.
+func (o *options) siteConfig() (*siteconfig.Config, error) {
+	path := o.configFile
+	if path == "" {
+		if _, err := os.Stat(siteconfig.DefaultFile); err != nil {
+			return nil, nil
+		}
+		path = siteconfig.DefaultFile
+	}
+	return siteconfig.Load(path)
+}
+
+func (o *options) scanOptions(siteCfg *siteconfig.Config) (scanner.ScanOptions, error) {
+	headers, err := scanner.ParseHeaderLines(strings.Join(o.headers, "\n"))
+	if err != nil {
+		return scanner.ScanOptions{}, err
+	}
+
+	var rules *scanner.ScanRules
+	if siteCfg != nil {
+		rules = siteCfg.Rules()
+		// Headers given as flags win over those named in the config.
+		configHeaders, err := siteCfg.Headers(os.LookupEnv)
+		if err != nil {
+			return scanner.ScanOptions{}, err
+		}
+		for name, value := range configHeaders {
+			if _, ok := headers[name]; ok {
+				continue
+			}
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[name] = value
+		}
+	}
+	cookies, err := scanner.ParseCookieLines(strings.Join(o.cookies, "\n"))
+	if err != nil {
+		return scanner.ScanOptions{}, err
+	}
+
+	creds := &scanner.Credentials{
+		BearerToken: o.bearer,
+		Headers:     headers,
+		Cookies:     cookies,
+	}
+	if o.basicAuth != "" {
+		user, pass, ok := strings.Cut(o.basicAuth, ":")
+		if !ok {
+			return scanner.ScanOptions{}, fmt.Errorf("invalid basic auth, expected \"user:password\"")
+		}
+		creds.BasicUser, creds.BasicPass = user, pass
+	}
+
+	if o.loginURL != "" {
+		fields, err := scanner.ParseFieldLines(strings.Join(o.loginFields, "\n"))
+		if err != nil {
+			return scanner.ScanOptions{}, err
+		}
+		creds.Login = &scanner.LoginStep{
+			URL:         o.loginURL,
+			Fields:      fields,
+			SuccessText: o.loginSuccess,
+		}
+	}
+	if creds.IsEmpty() {
+		creds = nil
+	}
+
+	return scanner.ScanOptions{Credentials: creds, Rules: rules}, nil
+}
+
+// openInput opens a file, or stdin for "-", and names it for the report.
+func openInput(path string, stdin io.Reader) (string, io.ReadCloser, error) {
+	if path == "-" {
+		return "stdin", io.NopCloser(stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Base(path), f, nil
+}
+
+func countSet(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func readBaseline(path string) (*scanner.Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := scanner.ReadBaseline(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+func writeBaseline(path string, b *scanner.Baseline) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type reportFile struct {
+	format scanner.ExportFormat
+	path   string
+}
+
+func parseReports(values []string) ([]reportFile, error) {
+	var reports []reportFile
+	for _, v := range values {
+		name, path, ok := strings.Cut(v, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid report %q, expected \"format=path\"", v)
+		}
+		format, ok := scanner.ParseExportFormat(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown report format %q, expected one of %s", name, formatList())
+		}
+		reports = append(reports, reportFile{format: format, path: path})
+	}
+	return reports, nil
+}
+
+func formatList() string {
+	names := make([]string, 0, len(scanner.ExportFormats))
+	for _, f := range scanner.ExportFormats {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/pkg/deadlink"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanRun is a finished scan as the export writers expect it.
+type scanRun struct {
+	scan    db.Scan
+	results []db.Result
+	// baseline is set when checking against a baseline file, with known
+	// holding its accepted links by normalized URL.
+	baseline *scanner.BaselineCheck
+	known    map[string]bool
+	// sources maps links to the pages they were found on.
+	sources map[string][]string
+}
+
+// newRun combines the reports of one or more start URLs.
+func newRun(reports []*scanner.Report, started, finished time.Time) *scanRun {
+	var results []db.Result
+	var startURLs []string
+	sources := make(map[string][]string)
+	for _, report := range reports {
+		results = append(results, report.Results...)
+		startURLs = append(startURLs, report.StartURL)
+		for link, pages := range report.Sources {
+			for _, page := range pages {
+				if !slices.Contains(sources[link], page) {
+					sources[link] = append(sources[link], page)
+				}
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].LinkUrl < results[j].LinkUrl })
+
+	return &scanRun{
+		scan: db.Scan{
+			ID:         reports[0].ScanID,
+			StartUrl:   strings.Join(startURLs, ", "),
+			StartedAt:  started,
+			FinishedAt: sql.NullTime{Time: finished, Valid: true},
+		},
+		results: results,
+		sources: sources,
+	}
+}
+
+func (r *scanRun) broken() int {
+	n := 0
+	for _, res := range r.results {
+		if scanner.IsBrokenResult(res) {
+			n++
+		}
+	}
+	return n
+}
+
+// failing counts the broken links that fail the run: all of them, or those
+// missing from the baseline.
+func (r *scanRun) failing() int {
+	if r.baseline != nil {
+		return len(r.baseline.New)
+	}
+	return r.broken()
+}
+
+// checkBaseline compares the run's broken links with the baseline.
+func (r *scanRun) checkBaseline(b *scanner.Baseline) {
+	check := b.Check(r.results)
+	r.baseline = &check
+	r.known = make(map[string]bool, len(check.Accepted))
+	for _, a := range check.Accepted {
+		r.known[deadlink.NormalizeURL(a.LinkUrl)] = true
+	}
+}
+
+// accepted reports whether a broken link is listed in the baseline, matching
+// URLs the way the baseline check does.
+func (r *scanRun) accepted(res db.Result) bool {
+	return r.known[deadlink.NormalizeURL(res.LinkUrl)]
+}
+
+// exported returns the results the export formats report: with a baseline,
+// its accepted broken links are left out so reports only show regressions.
+func (r *scanRun) exported() []db.Result {
+	if r.baseline == nil {
+		return r.results
+	}
+	var results []db.Result
+	for _, res := range r.results {
+		if scanner.IsBrokenResult(res) && r.accepted(res) {
+			continue
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// write prints the run in the given format; "text" is a human-readable
+// listing of broken links and warnings.
+func (r *scanRun) write(w io.Writer, format string, all bool) error {
+	if format != "text" {
+		f, _ := scanner.ParseExportFormat(format)
+		return scanner.WriteExport(w, f, r.scan, r.exported(), r.sources)
+	}
+
+	warnings := 0
+	for _, res := range r.results {
+		isBroken := scanner.IsBrokenResult(res)
+		known := isBroken && r.accepted(res)
+		if res.Warning != "" {
+			warnings++
+		}
+		if !all && (known || (!isBroken && res.Warning == "")) {
+			continue
+		}
+
+		mark := "ok"
+		switch {
+		case known:
+			mark = "KNOWN"
+		case isBroken:
+			mark = "FAIL"
+		case res.Warning != "":
+			mark = "WARN"
+		}
+		fmt.Fprintf(w, "%-5s %s (%s)\n", mark, res.LinkUrl, status(res))
+		if isBroken && res.ErrorDetail != "" {
+			fmt.Fprintf(w, "      %s\n", res.ErrorDetail)
+		}
+		if res.Warning != "" {
+			fmt.Fprintf(w, "      %s\n", res.Warning)
+		}
+	}
+
+	elapsed := r.scan.FinishedAt.Time.Sub(r.scan.StartedAt).Round(100 * time.Millisecond)
+	fmt.Fprintf(w, "\n%d links checked in %s: %d broken, %d warnings\n", len(r.results), elapsed, r.broken(), warnings)
+
+	if b := r.baseline; b != nil {
+		fmt.Fprintf(w, "Baseline: %d new broken, %d accepted\n", len(b.New), len(b.Accepted))
+		if len(b.Fixed) > 0 {
+			fmt.Fprintf(w, "%d baseline links are no longer broken, regenerate it with -update-baseline:\n", len(b.Fixed))
+			for _, l := range b.Fixed {
+				fmt.Fprintf(w, "      %s\n", l.URL)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *scanRun) writeFile(format scanner.ExportFormat, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := scanner.WriteExport(f, format, r.scan, r.exported(), r.sources); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s report: %w", format, err)
+	}
+	return f.Close()
+}
+
+func status(res db.Result) string {
+	if res.StatusCode > 0 {
+		return strconv.Itoa(int(res.StatusCode)) + " " + res.Category
+	}
+	return res.Category
+}
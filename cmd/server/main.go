@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"go-deadlink-scanner/internal/auth"
 	"go-deadlink-scanner/internal/config"
 	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/email"
+	"go-deadlink-scanner/internal/preset"
 	"go-deadlink-scanner/internal/routes"
 	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/internal/schedule"
+	"go-deadlink-scanner/internal/secret"
+	"go-deadlink-scanner/internal/store"
 	"go-deadlink-scanner/internal/user"
+	"go-deadlink-scanner/internal/webhook"
 	"log"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,14 +25,6 @@ import (
 func main() {
 	cfg := config.LoadConfig()
 
-	connect, err := sql.Open("postgres", cfg.DBUrl)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer connect.Close()
-
-	queries := db.New(connect)
-
 	app := fiber.New(fiber.Config{
 		AppName: "Go Dead Link Scanner",
 	})
@@ -38,16 +37,20 @@ func main() {
 		return c.SendString("OK")
 	})
 
-	userService := user.NewService(queries)
-	scannerService := scanner.NewService(queries, cfg.MaxScannerWorkers)
-
-	userHandler := user.NewHandler(userService)
-	scannerHandler := scanner.NewHandler(scannerService)
-
-	middleware := auth.NewMiddleware(queries)
+	switch cfg.Storage {
+	case "memory":
+		registerDemo(app, cfg)
+	case "postgres":
+		connect, err := sql.Open("postgres", cfg.DBUrl)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer connect.Close()
 
-	r := routes.New(app, userHandler, scannerHandler, middleware)
-	r.Register()
+		register(app, cfg, db.New(connect))
+	default:
+		log.Fatalf("Unknown STORAGE %q, want postgres or memory", cfg.Storage)
+	}
 
 	log.Printf("Server started on :%s", cfg.Port)
 	if cfg.EnableTLS {
@@ -61,3 +64,55 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+func register(app *fiber.App, cfg *config.Config, queries *db.Queries) {
+	userService := user.NewService(queries)
+	scannerService, err := scanner.NewService(store.NewPostgres(queries), queries, cfg)
+	if err != nil {
+		log.Fatal("Failed to configure scanner:", err)
+	}
+	scheduleService := schedule.NewService(queries, scannerService, cfg.ScheduleInterval)
+	box, err := secret.NewBox(cfg.CredentialsKey)
+	if err != nil {
+		log.Printf("creating webhooks disabled: %v", err)
+	}
+	webhookService := webhook.NewService(queries, box, cfg.BaseURL)
+	webhookService.SealSecrets(context.Background())
+	scannerService.Subscribe(webhookService.HandleEvent)
+	emailService := email.NewService(queries, scannerService, email.NewMailer(cfg), cfg.BaseURL)
+	scannerService.Subscribe(emailService.HandleEvent)
+	presetService := preset.NewService(queries)
+
+	userHandler := user.NewHandler(userService)
+	scannerHandler := scanner.NewHandler(scannerService)
+	scheduleHandler := schedule.NewHandler(scheduleService)
+	webhookHandler := webhook.NewHandler(webhookService)
+	emailHandler := email.NewHandler(emailService)
+	presetHandler := preset.NewHandler(presetService)
+
+	middleware := auth.NewMiddleware(queries)
+
+	r := routes.New(app, userHandler, scannerHandler, scheduleHandler, webhookHandler, emailHandler, presetHandler, middleware)
+	r.Register()
+
+	go scheduleService.Run(context.Background())
+	go scannerService.RunCachePruning(context.Background())
+	go emailService.RunDigests(context.Background())
+}
+
+// registerDemo serves only the scanner, keeping the latest scans in memory.
+// There are no accounts: every visitor is auth.DemoUserID and sees the same
+// scans.
+func registerDemo(app *fiber.App, cfg *config.Config) {
+	scannerService, err := scanner.NewService(store.NewMemory(cfg.MemoryMaxScans), nil, cfg)
+	if err != nil {
+		log.Fatal("Failed to configure scanner:", err)
+	}
+
+	r := routes.New(app, nil, scanner.NewHandler(scannerService), nil, nil, nil, nil, auth.NewDemoMiddleware())
+	r.Register()
+
+	go scannerService.RunCachePruning(context.Background())
+
+	log.Println("Demo mode: scans are kept in memory and lost on restart")
+}
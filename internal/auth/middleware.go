@@ -8,18 +8,40 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// DemoUserID is the user every request acts as when running without a
+// database.
+const DemoUserID int32 = 1
+
 type Middleware struct {
 	Queries *db.Queries
+	// demo lets every request in without a session.
+	demo bool
 }
 
+// NewMiddleware checks session tokens against the database. It panics
+// without queries, so that a wiring mistake cannot switch off
+// authentication.
 func NewMiddleware(queries *db.Queries) *Middleware {
+	if queries == nil {
+		panic("auth: NewMiddleware needs queries, use NewDemoMiddleware to run without a database")
+	}
 	return &Middleware{
 		Queries: queries,
 	}
 }
 
+// NewDemoMiddleware lets every request in as DemoUserID.
+func NewDemoMiddleware() *Middleware {
+	return &Middleware{demo: true}
+}
+
 func (m *Middleware) RequireAuth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if m.demo {
+			c.Locals("user_id", DemoUserID)
+			return c.Next()
+		}
+
 		token := c.Cookies("session_token")
 		if token == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
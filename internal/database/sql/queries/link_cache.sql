@@ -0,0 +1,18 @@
+-- name: GetLinkCache :one
+SELECT * FROM link_cache
+WHERE url = $1 AND checked_at > $2;
+
+-- name: UpsertLinkCache :exec
+INSERT INTO link_cache (url, category, status_code, content_type, error_detail, warning, checked_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (url) DO UPDATE SET
+    category = EXCLUDED.category,
+    status_code = EXCLUDED.status_code,
+    content_type = EXCLUDED.content_type,
+    error_detail = EXCLUDED.error_detail,
+    warning = EXCLUDED.warning,
+    checked_at = EXCLUDED.checked_at;
+
+-- name: DeleteExpiredLinkCache :exec
+DELETE FROM link_cache
+WHERE checked_at < $1;
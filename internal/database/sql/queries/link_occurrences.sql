@@ -0,0 +1,9 @@
+-- name: CreateLinkOccurrence :exec
+INSERT INTO link_occurrences (scan_id, link_url, page_url)
+VALUES ($1, $2, $3)
+ON CONFLICT (scan_id, link_url, page_url) DO NOTHING;
+
+-- name: ListLinkOccurrencesByScan :many
+SELECT * FROM link_occurrences
+WHERE scan_id = $1
+ORDER BY link_url, page_url;
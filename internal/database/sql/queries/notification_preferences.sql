@@ -0,0 +1,23 @@
+-- name: GetNotificationPreferences :one
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreferences :one
+INSERT INTO notification_preferences (user_id, scan_finished, new_broken, weekly_digest, updated_at)
+VALUES ($1, $2, $3, $4, now())
+ON CONFLICT (user_id) DO UPDATE SET
+    scan_finished = EXCLUDED.scan_finished,
+    new_broken = EXCLUDED.new_broken,
+    weekly_digest = EXCLUDED.weekly_digest,
+    updated_at = EXCLUDED.updated_at
+    RETURNING *;
+
+-- name: ListDigestRecipients :many
+SELECT u.id, u.email FROM notification_preferences p
+JOIN users u ON u.id = p.user_id
+WHERE p.weekly_digest AND (p.digest_sent_at IS NULL OR p.digest_sent_at < $1)
+ORDER BY u.id;
+
+-- name: MarkDigestSent :exec
+UPDATE notification_preferences SET digest_sent_at = $2
+WHERE user_id = $1;
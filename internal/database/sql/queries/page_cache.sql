@@ -0,0 +1,12 @@
+-- name: GetPageCache :one
+SELECT * FROM page_cache
+WHERE user_id = $1 AND url = $2;
+
+-- name: UpsertPageCache :exec
+INSERT INTO page_cache (user_id, url, etag, last_modified, links, updated_at)
+VALUES ($1, $2, $3, $4, $5, now())
+ON CONFLICT (user_id, url) DO UPDATE SET
+    etag = EXCLUDED.etag,
+    last_modified = EXCLUDED.last_modified,
+    links = EXCLUDED.links,
+    updated_at = now();
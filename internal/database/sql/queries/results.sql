@@ -1,6 +1,7 @@
 -- name: CreateResult :one
-INSERT INTO results (user_id, page_url, link_url, status)
-VALUES ($1, $2, $3, $4)
+INSERT INTO results (user_id, page_url, link_url, warning, category, status_code, content_type, error_detail,
+                     dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, severity)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
     RETURNING *;
 
 -- name: GetResultByID :one
@@ -13,6 +14,26 @@ WHERE user_id = $1
 ORDER BY checked_at DESC
     LIMIT $2 OFFSET $3;
 
+-- name: ListResultsByUserFiltered :many
+SELECT * FROM results
+WHERE user_id = @user_id
+  AND (@page_url::text = '' OR page_url = @page_url::text)
+  AND (@category::text = '' OR category = @category::text)
+ORDER BY checked_at DESC
+    LIMIT @row_limit OFFSET @row_offset;
+
+-- name: ListResultsByScan :many
+SELECT * FROM results
+WHERE scan_id = $1
+ORDER BY link_url;
+
 -- name: DeleteResultsByUser :exec
 DELETE FROM results
 WHERE user_id = $1;
+
+-- name: UpdateResultStatus :exec
+UPDATE results
+SET category = $2, status_code = $3, content_type = $4, error_detail = $5, warning = $6,
+    dns_ms = $7, connect_ms = $8, tls_ms = $9, ttfb_ms = $10, total_ms = $11,
+    remote_ip = $12, cached = $13, checked_at = now(), fixed_at = $14, severity = $15
+WHERE id = $1;
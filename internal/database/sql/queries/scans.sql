@@ -0,0 +1,33 @@
+-- name: CreateScan :one
+INSERT INTO scans (user_id, start_url, credentials, site)
+VALUES ($1, $2, $3, $4)
+    RETURNING *;
+
+-- name: GetScanByID :one
+SELECT * FROM scans
+WHERE id = $1;
+
+-- name: ListScansByUser :many
+SELECT * FROM scans
+WHERE user_id = $1
+ORDER BY started_at DESC
+    LIMIT $2;
+
+-- name: FinishScan :exec
+UPDATE scans SET finished_at = now()
+WHERE id = $1;
+
+-- name: ListScansByUserSince :many
+SELECT * FROM scans
+WHERE user_id = $1 AND started_at >= $2
+ORDER BY started_at DESC;
+
+-- name: GetPreviousScan :one
+SELECT * FROM scans
+WHERE user_id = $1 AND site = $2 AND started_at < $3 AND finished_at IS NOT NULL
+ORDER BY started_at DESC
+    LIMIT 1;
+
+-- name: DeleteScan :exec
+DELETE FROM scans
+WHERE id = $1;
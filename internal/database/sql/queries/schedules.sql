@@ -0,0 +1,43 @@
+-- name: CreateSchedule :one
+INSERT INTO schedules (user_id, start_url, cron_expr, timezone, options, bypass_cache, next_run_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING *;
+
+-- name: GetScheduleByID :one
+SELECT * FROM schedules
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListSchedulesByUser :many
+SELECT * FROM schedules
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: SetScheduleEnabled :one
+UPDATE schedules SET enabled = $3, next_run_at = $4
+WHERE id = $1 AND user_id = $2
+    RETURNING *;
+
+-- name: DeleteSchedule :exec
+DELETE FROM schedules
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListDueSchedules :many
+SELECT * FROM schedules
+WHERE enabled AND running_since IS NULL AND next_run_at <= $1
+ORDER BY next_run_at;
+
+-- name: ClaimSchedule :execrows
+UPDATE schedules SET running_since = $2
+WHERE id = $1 AND running_since IS NULL;
+
+-- name: FinishScheduleRun :exec
+UPDATE schedules SET running_since = NULL, last_run_at = $2, last_scan_id = $3, last_error = $4, next_run_at = $5
+WHERE id = $1;
+
+-- name: RefreshScheduleClaim :exec
+UPDATE schedules SET running_since = $2
+WHERE id = $1 AND running_since IS NOT NULL;
+
+-- name: ReleaseStaleSchedules :exec
+UPDATE schedules SET running_since = NULL
+WHERE running_since < $1;
@@ -0,0 +1,21 @@
+-- name: UpsertSitePreset :one
+INSERT INTO site_presets (user_id, site, source, rules)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, site) DO UPDATE SET
+    source = EXCLUDED.source,
+    rules = EXCLUDED.rules,
+    updated_at = now()
+    RETURNING *;
+
+-- name: GetSitePreset :one
+SELECT * FROM site_presets
+WHERE user_id = $1 AND site = $2;
+
+-- name: ListSitePresetsByUser :many
+SELECT * FROM site_presets
+WHERE user_id = $1
+ORDER BY site;
+
+-- name: DeleteSitePreset :exec
+DELETE FROM site_presets
+WHERE id = $1 AND user_id = $2;
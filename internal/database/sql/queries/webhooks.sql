@@ -0,0 +1,43 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, sealed_secret, events, format, site)
+VALUES ($1, $2, $3, $4, $5, $6)
+    RETURNING *;
+
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListWebhooksByUser :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE user_id = @user_id AND @event::text = ANY(events)
+ORDER BY id;
+
+-- name: ListUnsealedWebhooks :many
+SELECT * FROM webhooks
+WHERE sealed_secret IS NULL AND secret <> ''
+ORDER BY id;
+
+-- name: SealWebhookSecret :exec
+UPDATE webhooks
+SET sealed_secret = $2, secret = ''
+WHERE id = $1;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload, attempt, status_code, error, success)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+    LIMIT $2;
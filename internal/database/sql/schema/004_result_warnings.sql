@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE results ADD COLUMN warning TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE results DROP COLUMN warning;
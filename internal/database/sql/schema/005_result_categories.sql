@@ -0,0 +1,42 @@
+-- +goose Up
+ALTER TABLE results
+    ADD COLUMN category VARCHAR(32) NOT NULL DEFAULT 'ok',
+    ADD COLUMN status_code INT NOT NULL DEFAULT 0,
+    ADD COLUMN content_type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN error_detail TEXT NOT NULL DEFAULT '';
+
+UPDATE results SET
+    category = CASE
+        WHEN status LIKE 'ok%' THEN 'ok'
+        WHEN status LIKE '404%' OR status LIKE 'Client Error%' THEN 'client_error'
+        WHEN status LIKE 'Server Error%' THEN 'server_error'
+        WHEN status LIKE 'Redirect%' THEN 'redirect'
+        ELSE 'network_error'
+    END,
+    status_code = CASE
+        WHEN status LIKE 'ok%' THEN 200
+        ELSE COALESCE(substring(status from '([0-9]{3})')::INT, 0)
+    END,
+    content_type = COALESCE(substring(status from '^ok \((.*)\)$'), ''),
+    error_detail = CASE WHEN status LIKE 'ok%' THEN '' ELSE status END;
+
+ALTER TABLE results DROP COLUMN status;
+
+CREATE INDEX idx_results_user_category ON results(user_id, category);
+
+-- +goose Down
+DROP INDEX idx_results_user_category;
+
+ALTER TABLE results ADD COLUMN status VARCHAR(100) NOT NULL DEFAULT '';
+
+UPDATE results SET status = CASE
+    WHEN category = 'ok' THEN 'ok (' || content_type || ')'
+    WHEN status_code > 0 THEN category || ' (' || status_code || ')'
+    ELSE 'error'
+END;
+
+ALTER TABLE results
+    DROP COLUMN category,
+    DROP COLUMN status_code,
+    DROP COLUMN content_type,
+    DROP COLUMN error_detail;
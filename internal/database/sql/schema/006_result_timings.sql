@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE results
+    ADD COLUMN dns_ms INT NOT NULL DEFAULT 0,
+    ADD COLUMN connect_ms INT NOT NULL DEFAULT 0,
+    ADD COLUMN tls_ms INT NOT NULL DEFAULT 0,
+    ADD COLUMN ttfb_ms INT NOT NULL DEFAULT 0,
+    ADD COLUMN total_ms INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE results
+    DROP COLUMN dns_ms,
+    DROP COLUMN connect_ms,
+    DROP COLUMN tls_ms,
+    DROP COLUMN ttfb_ms,
+    DROP COLUMN total_ms;
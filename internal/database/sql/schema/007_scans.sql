@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE scans (
+id SERIAL PRIMARY KEY,
+user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+start_url TEXT NOT NULL,
+credentials BYTEA,
+started_at TIMESTAMP NOT NULL DEFAULT now(),
+finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_scans_user_id ON scans(user_id);
+
+ALTER TABLE results ADD COLUMN scan_id INT REFERENCES scans(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_results_scan_id ON results(scan_id);
+
+-- +goose Down
+ALTER TABLE results DROP COLUMN scan_id;
+
+DROP TABLE scans;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE results ADD COLUMN remote_ip TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE results DROP COLUMN remote_ip;
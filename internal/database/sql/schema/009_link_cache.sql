@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE link_cache (
+url TEXT PRIMARY KEY,
+category VARCHAR(32) NOT NULL,
+status_code INT NOT NULL DEFAULT 0,
+content_type TEXT NOT NULL DEFAULT '',
+error_detail TEXT NOT NULL DEFAULT '',
+warning TEXT NOT NULL DEFAULT '',
+checked_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE results ADD COLUMN cached BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE results DROP COLUMN cached;
+
+DROP TABLE link_cache;
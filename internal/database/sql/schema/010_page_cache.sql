@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE page_cache (
+user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+url TEXT NOT NULL,
+etag TEXT NOT NULL DEFAULT '',
+last_modified TEXT NOT NULL DEFAULT '',
+links TEXT[] NOT NULL DEFAULT '{}',
+updated_at TIMESTAMP NOT NULL DEFAULT now(),
+PRIMARY KEY (user_id, url)
+);
+
+-- +goose Down
+DROP TABLE page_cache;
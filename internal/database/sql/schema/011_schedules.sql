@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE schedules (
+id SERIAL PRIMARY KEY,
+user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+start_url TEXT NOT NULL,
+cron_expr TEXT NOT NULL,
+timezone TEXT NOT NULL DEFAULT 'UTC',
+options BYTEA,
+bypass_cache BOOLEAN NOT NULL DEFAULT false,
+enabled BOOLEAN NOT NULL DEFAULT true,
+running_since TIMESTAMP,
+next_run_at TIMESTAMP NOT NULL,
+last_run_at TIMESTAMP,
+last_scan_id INT REFERENCES scans(id) ON DELETE SET NULL,
+last_error TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_schedules_user_id ON schedules(user_id);
+CREATE INDEX idx_schedules_next_run_at ON schedules(next_run_at) WHERE enabled;
+
+-- +goose Down
+DROP TABLE schedules;
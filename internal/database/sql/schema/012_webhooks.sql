@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE webhooks (
+id SERIAL PRIMARY KEY,
+user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+url TEXT NOT NULL,
+secret TEXT NOT NULL,
+events TEXT[] NOT NULL DEFAULT '{}',
+created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhooks_user_id ON webhooks(user_id);
+
+CREATE TABLE webhook_deliveries (
+id SERIAL PRIMARY KEY,
+webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+event TEXT NOT NULL,
+payload TEXT NOT NULL,
+attempt INT NOT NULL,
+status_code INT NOT NULL DEFAULT 0,
+error TEXT NOT NULL DEFAULT '',
+success BOOLEAN NOT NULL DEFAULT false,
+created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhooks;
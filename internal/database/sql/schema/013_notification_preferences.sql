@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE notification_preferences (
+user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+scan_finished BOOLEAN NOT NULL DEFAULT false,
+new_broken BOOLEAN NOT NULL DEFAULT false,
+weekly_digest BOOLEAN NOT NULL DEFAULT false,
+digest_sent_at TIMESTAMP,
+updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE webhooks ADD COLUMN format TEXT NOT NULL DEFAULT 'generic';
+ALTER TABLE webhooks ADD COLUMN site TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE webhooks DROP COLUMN site;
+ALTER TABLE webhooks DROP COLUMN format;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE site_presets (
+id SERIAL PRIMARY KEY,
+user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+site TEXT NOT NULL,
+source TEXT NOT NULL,
+rules TEXT NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+updated_at TIMESTAMP NOT NULL DEFAULT now(),
+UNIQUE (user_id, site)
+);
+
+-- +goose Down
+DROP TABLE site_presets;
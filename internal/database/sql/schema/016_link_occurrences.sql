@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE link_occurrences (
+id SERIAL PRIMARY KEY,
+scan_id INT NOT NULL REFERENCES scans(id) ON DELETE CASCADE,
+link_url TEXT NOT NULL,
+page_url TEXT NOT NULL,
+UNIQUE (scan_id, link_url, page_url)
+);
+
+ALTER TABLE results ADD COLUMN fixed_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE results DROP COLUMN fixed_at;
+
+DROP TABLE link_occurrences;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Secrets are sealed with CREDENTIALS_KEY. secret only holds the plaintext
+-- of older webhooks until the server seals them at startup.
+ALTER TABLE webhooks ADD COLUMN sealed_secret BYTEA;
+ALTER TABLE webhooks ALTER COLUMN secret SET DEFAULT '';
+
+-- +goose Down
+ALTER TABLE webhooks ALTER COLUMN secret DROP DEFAULT;
+ALTER TABLE webhooks DROP COLUMN sealed_secret;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- site is the lowercased host of start_url (scanner.SiteOf), so the previous
+-- scan of a site can be looked up directly.
+ALTER TABLE scans ADD COLUMN site TEXT NOT NULL DEFAULT '';
+
+UPDATE scans SET site = coalesce(
+    nullif(lower(btrim(substring(start_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?(\[[^]]*\]|[^/?#:]+)'), '[]')), ''),
+    start_url);
+
+CREATE INDEX idx_scans_user_site ON scans(user_id, site, started_at);
+
+-- +goose Down
+DROP INDEX idx_scans_user_site;
+
+ALTER TABLE scans DROP COLUMN site;
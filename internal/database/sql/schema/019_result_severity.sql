@@ -0,0 +1,7 @@
+-- +goose Up
+-- severity is 'warning' for failures the scan rules report as warnings. They
+-- keep the category they were checked with but do not count as broken.
+ALTER TABLE results ADD COLUMN severity VARCHAR(16) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE results DROP COLUMN severity;
@@ -5,16 +5,93 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type LinkCache struct {
+	Url         string
+	Category    string
+	StatusCode  int32
+	ContentType string
+	ErrorDetail string
+	Warning     string
+	CheckedAt   time.Time
+}
+
+type LinkOccurrence struct {
+	ID      int32
+	ScanID  int32
+	LinkUrl string
+	PageUrl string
+}
+
+type NotificationPreference struct {
+	UserID       int32
+	ScanFinished bool
+	NewBroken    bool
+	WeeklyDigest bool
+	DigestSentAt sql.NullTime
+	UpdatedAt    time.Time
+}
+
+type PageCache struct {
+	UserID       int32
+	Url          string
+	Etag         string
+	LastModified string
+	Links        []string
+	UpdatedAt    time.Time
+}
+
 type Result struct {
-	ID        int32
-	UserID    int32
-	PageUrl   string
-	LinkUrl   string
-	Status    string
-	CheckedAt time.Time
+	ID          int32
+	UserID      int32
+	PageUrl     string
+	LinkUrl     string
+	CheckedAt   time.Time
+	Warning     string
+	Category    string
+	StatusCode  int32
+	ContentType string
+	ErrorDetail string
+	DnsMs       int32
+	ConnectMs   int32
+	TlsMs       int32
+	TtfbMs      int32
+	TotalMs     int32
+	ScanID      sql.NullInt32
+	RemoteIp    string
+	Cached      bool
+	FixedAt     sql.NullTime
+	Severity    string
+}
+
+type Scan struct {
+	ID          int32
+	UserID      int32
+	StartUrl    string
+	Credentials []byte
+	StartedAt   time.Time
+	FinishedAt  sql.NullTime
+	Site        string
+}
+
+type Schedule struct {
+	ID           int32
+	UserID       int32
+	StartUrl     string
+	CronExpr     string
+	Timezone     string
+	Options      []byte
+	BypassCache  bool
+	Enabled      bool
+	RunningSince sql.NullTime
+	NextRunAt    time.Time
+	LastRunAt    sql.NullTime
+	LastScanID   sql.NullInt32
+	LastError    string
+	CreatedAt    time.Time
 }
 
 type Session struct {
@@ -25,9 +102,43 @@ type Session struct {
 	CreatedAt    time.Time
 }
 
+type SitePreset struct {
+	ID        int32
+	UserID    int32
+	Site      string
+	Source    string
+	Rules     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type User struct {
 	ID        int32
 	Email     string
 	Password  string
 	CreatedAt time.Time
 }
+
+type Webhook struct {
+	ID           int32
+	UserID       int32
+	Url          string
+	Secret       string
+	Events       []string
+	CreatedAt    time.Time
+	Format       string
+	Site         string
+	SealedSecret []byte
+}
+
+type WebhookDelivery struct {
+	ID         int32
+	WebhookID  int32
+	Event      string
+	Payload    string
+	Attempt    int32
+	StatusCode int32
+	Error      string
+	Success    bool
+	CreatedAt  time.Time
+}
@@ -7,19 +7,34 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createResult = `-- name: CreateResult :one
-INSERT INTO results (user_id, page_url, link_url, status)
-VALUES ($1, $2, $3, $4)
-    RETURNING id, user_id, page_url, link_url, status, checked_at
+INSERT INTO results (user_id, page_url, link_url, warning, category, status_code, content_type, error_detail,
+                     dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, severity)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+    RETURNING id, user_id, page_url, link_url, checked_at, warning, category, status_code, content_type, error_detail, dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, fixed_at, severity
 `
 
 type CreateResultParams struct {
-	UserID  int32
-	PageUrl string
-	LinkUrl string
-	Status  string
+	UserID      int32
+	PageUrl     string
+	LinkUrl     string
+	Warning     string
+	Category    string
+	StatusCode  int32
+	ContentType string
+	ErrorDetail string
+	DnsMs       int32
+	ConnectMs   int32
+	TlsMs       int32
+	TtfbMs      int32
+	TotalMs     int32
+	ScanID      sql.NullInt32
+	RemoteIp    string
+	Cached      bool
+	Severity    string
 }
 
 func (q *Queries) CreateResult(ctx context.Context, arg CreateResultParams) (Result, error) {
@@ -27,7 +42,20 @@ func (q *Queries) CreateResult(ctx context.Context, arg CreateResultParams) (Res
 		arg.UserID,
 		arg.PageUrl,
 		arg.LinkUrl,
-		arg.Status,
+		arg.Warning,
+		arg.Category,
+		arg.StatusCode,
+		arg.ContentType,
+		arg.ErrorDetail,
+		arg.DnsMs,
+		arg.ConnectMs,
+		arg.TlsMs,
+		arg.TtfbMs,
+		arg.TotalMs,
+		arg.ScanID,
+		arg.RemoteIp,
+		arg.Cached,
+		arg.Severity,
 	)
 	var i Result
 	err := row.Scan(
@@ -35,8 +63,22 @@ func (q *Queries) CreateResult(ctx context.Context, arg CreateResultParams) (Res
 		&i.UserID,
 		&i.PageUrl,
 		&i.LinkUrl,
-		&i.Status,
 		&i.CheckedAt,
+		&i.Warning,
+		&i.Category,
+		&i.StatusCode,
+		&i.ContentType,
+		&i.ErrorDetail,
+		&i.DnsMs,
+		&i.ConnectMs,
+		&i.TlsMs,
+		&i.TtfbMs,
+		&i.TotalMs,
+		&i.ScanID,
+		&i.RemoteIp,
+		&i.Cached,
+		&i.FixedAt,
+		&i.Severity,
 	)
 	return i, err
 }
@@ -52,7 +94,7 @@ func (q *Queries) DeleteResultsByUser(ctx context.Context, userID int32) error {
 }
 
 const getResultByID = `-- name: GetResultByID :one
-SELECT id, user_id, page_url, link_url, status, checked_at FROM results
+SELECT id, user_id, page_url, link_url, checked_at, warning, category, status_code, content_type, error_detail, dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, fixed_at, severity FROM results
 WHERE id = $1
 `
 
@@ -64,14 +106,78 @@ func (q *Queries) GetResultByID(ctx context.Context, id int32) (Result, error) {
 		&i.UserID,
 		&i.PageUrl,
 		&i.LinkUrl,
-		&i.Status,
 		&i.CheckedAt,
+		&i.Warning,
+		&i.Category,
+		&i.StatusCode,
+		&i.ContentType,
+		&i.ErrorDetail,
+		&i.DnsMs,
+		&i.ConnectMs,
+		&i.TlsMs,
+		&i.TtfbMs,
+		&i.TotalMs,
+		&i.ScanID,
+		&i.RemoteIp,
+		&i.Cached,
+		&i.FixedAt,
+		&i.Severity,
 	)
 	return i, err
 }
 
+const listResultsByScan = `-- name: ListResultsByScan :many
+SELECT id, user_id, page_url, link_url, checked_at, warning, category, status_code, content_type, error_detail, dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, fixed_at, severity FROM results
+WHERE scan_id = $1
+ORDER BY link_url
+`
+
+func (q *Queries) ListResultsByScan(ctx context.Context, scanID sql.NullInt32) ([]Result, error) {
+	rows, err := q.db.QueryContext(ctx, listResultsByScan, scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Result
+	for rows.Next() {
+		var i Result
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PageUrl,
+			&i.LinkUrl,
+			&i.CheckedAt,
+			&i.Warning,
+			&i.Category,
+			&i.StatusCode,
+			&i.ContentType,
+			&i.ErrorDetail,
+			&i.DnsMs,
+			&i.ConnectMs,
+			&i.TlsMs,
+			&i.TtfbMs,
+			&i.TotalMs,
+			&i.ScanID,
+			&i.RemoteIp,
+			&i.Cached,
+			&i.FixedAt,
+			&i.Severity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listResultsByUser = `-- name: ListResultsByUser :many
-SELECT id, user_id, page_url, link_url, status, checked_at FROM results
+SELECT id, user_id, page_url, link_url, checked_at, warning, category, status_code, content_type, error_detail, dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, fixed_at, severity FROM results
 WHERE user_id = $1
 ORDER BY checked_at DESC
     LIMIT $2 OFFSET $3
@@ -97,8 +203,89 @@ func (q *Queries) ListResultsByUser(ctx context.Context, arg ListResultsByUserPa
 			&i.UserID,
 			&i.PageUrl,
 			&i.LinkUrl,
-			&i.Status,
 			&i.CheckedAt,
+			&i.Warning,
+			&i.Category,
+			&i.StatusCode,
+			&i.ContentType,
+			&i.ErrorDetail,
+			&i.DnsMs,
+			&i.ConnectMs,
+			&i.TlsMs,
+			&i.TtfbMs,
+			&i.TotalMs,
+			&i.ScanID,
+			&i.RemoteIp,
+			&i.Cached,
+			&i.FixedAt,
+			&i.Severity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listResultsByUserFiltered = `-- name: ListResultsByUserFiltered :many
+SELECT id, user_id, page_url, link_url, checked_at, warning, category, status_code, content_type, error_detail, dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, scan_id, remote_ip, cached, fixed_at, severity FROM results
+WHERE user_id = $1
+  AND ($2::text = '' OR page_url = $2::text)
+  AND ($3::text = '' OR category = $3::text)
+ORDER BY checked_at DESC
+    LIMIT $4 OFFSET $5
+`
+
+type ListResultsByUserFilteredParams struct {
+	UserID    int32
+	PageUrl   string
+	Category  string
+	RowLimit  int32
+	RowOffset int32
+}
+
+func (q *Queries) ListResultsByUserFiltered(ctx context.Context, arg ListResultsByUserFilteredParams) ([]Result, error) {
+	rows, err := q.db.QueryContext(ctx, listResultsByUserFiltered,
+		arg.UserID,
+		arg.PageUrl,
+		arg.Category,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Result
+	for rows.Next() {
+		var i Result
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PageUrl,
+			&i.LinkUrl,
+			&i.CheckedAt,
+			&i.Warning,
+			&i.Category,
+			&i.StatusCode,
+			&i.ContentType,
+			&i.ErrorDetail,
+			&i.DnsMs,
+			&i.ConnectMs,
+			&i.TlsMs,
+			&i.TtfbMs,
+			&i.TotalMs,
+			&i.ScanID,
+			&i.RemoteIp,
+			&i.Cached,
+			&i.FixedAt,
+			&i.Severity,
 		); err != nil {
 			return nil, err
 		}
@@ -112,3 +299,50 @@ func (q *Queries) ListResultsByUser(ctx context.Context, arg ListResultsByUserPa
 	}
 	return items, nil
 }
+
+const updateResultStatus = `-- name: UpdateResultStatus :exec
+UPDATE results
+SET category = $2, status_code = $3, content_type = $4, error_detail = $5, warning = $6,
+    dns_ms = $7, connect_ms = $8, tls_ms = $9, ttfb_ms = $10, total_ms = $11,
+    remote_ip = $12, cached = $13, checked_at = now(), fixed_at = $14, severity = $15
+WHERE id = $1
+`
+
+type UpdateResultStatusParams struct {
+	ID          int32
+	Category    string
+	StatusCode  int32
+	ContentType string
+	ErrorDetail string
+	Warning     string
+	DnsMs       int32
+	ConnectMs   int32
+	TlsMs       int32
+	TtfbMs      int32
+	TotalMs     int32
+	RemoteIp    string
+	Cached      bool
+	FixedAt     sql.NullTime
+	Severity    string
+}
+
+func (q *Queries) UpdateResultStatus(ctx context.Context, arg UpdateResultStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateResultStatus,
+		arg.ID,
+		arg.Category,
+		arg.StatusCode,
+		arg.ContentType,
+		arg.ErrorDetail,
+		arg.Warning,
+		arg.DnsMs,
+		arg.ConnectMs,
+		arg.TlsMs,
+		arg.TtfbMs,
+		arg.TotalMs,
+		arg.RemoteIp,
+		arg.Cached,
+		arg.FixedAt,
+		arg.Severity,
+	)
+	return err
+}
@@ -0,0 +1,132 @@
+package email
+
+import (
+	emailui "go-deadlink-scanner/internal/templates/email"
+	"go-deadlink-scanner/internal/ui"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) NotificationsPage(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	prefs, err := h.service.Preferences(c.Context(), userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading preferences: " + err.Error())
+	}
+	address, err := h.service.Address(c.Context(), userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading user: " + err.Error())
+	}
+
+	prefsView := toPreferences(prefs.ScanFinished, prefs.NewBroken, prefs.WeeklyDigest)
+	return ui.RenderComponent(c, emailui.NotificationsPage(prefsView, h.service.Enabled(), address))
+}
+
+func (h *Handler) GetPreferences(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	prefs, err := h.service.Preferences(c.Context(), userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load preferences",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"email_enabled": h.service.Enabled(),
+		"scan_finished": prefs.ScanFinished,
+		"new_broken":    prefs.NewBroken,
+		"weekly_digest": prefs.WeeklyDigest,
+	})
+}
+
+func (h *Handler) SavePreferences(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	scanFinished := isChecked(c.FormValue("scan_finished"))
+	newBroken := isChecked(c.FormValue("new_broken"))
+	weeklyDigest := isChecked(c.FormValue("weekly_digest"))
+
+	if _, err := h.service.SavePreferences(c.Context(), userId, scanFinished, newBroken, weeklyDigest); err != nil {
+		if ui.IsHX(c) {
+			return h.renderForm(c, userId, "", []string{err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to save preferences",
+		})
+	}
+
+	if ui.IsHX(c) {
+		return h.renderForm(c, userId, "Saved.", nil)
+	}
+	return h.GetPreferences(c)
+}
+
+func (h *Handler) SendTest(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	if err := h.service.SendTest(c.Context(), userId); err != nil {
+		if ui.IsHX(c) {
+			return h.renderForm(c, userId, "", []string{"Test email failed: " + err.Error()})
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if ui.IsHX(c) {
+		return h.renderForm(c, userId, "Test email sent.", nil)
+	}
+	return c.JSON(fiber.Map{"sent": true})
+}
+
+// renderForm re-renders the form with the values just posted, so unsaved
+// changes survive a test email.
+func (h *Handler) renderForm(c *fiber.Ctx, userID int32, notice string, errs []string) error {
+	address, err := h.service.Address(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading user: " + err.Error())
+	}
+
+	prefs := toPreferences(isChecked(c.FormValue("scan_finished")), isChecked(c.FormValue("new_broken")), isChecked(c.FormValue("weekly_digest")))
+	return ui.RenderComponent(c, emailui.PreferencesForm(prefs, h.service.Enabled(), address, notice, errs))
+}
+
+func toPreferences(scanFinished, newBroken, weeklyDigest bool) emailui.Preferences {
+	return emailui.Preferences{ScanFinished: scanFinished, NewBroken: newBroken, WeeklyDigest: weeklyDigest}
+}
+
+func isChecked(value string) bool {
+	return value == "on" || value == "true"
+}
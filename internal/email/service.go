@@ -0,0 +1,235 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/scanner"
+	emailui "go-deadlink-scanner/internal/templates/email"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// Digests go out on Mondays from digestHour (server time), at most once
+// every digestInterval per user.
+const (
+	digestWeekday  = time.Monday
+	digestHour     = 8
+	digestInterval = 6 * 24 * time.Hour
+)
+
+type Service struct {
+	queries *db.Queries
+	scanner *scanner.Service
+	mailer  *Mailer
+	baseURL string
+}
+
+func NewService(queries *db.Queries, scannerService *scanner.Service, mailer *Mailer, baseURL string) *Service {
+	return &Service{queries: queries, scanner: scannerService, mailer: mailer, baseURL: baseURL}
+}
+
+func (s *Service) Enabled() bool {
+	return s.mailer.Enabled()
+}
+
+// Preferences returns the user's settings. Users who never saved them
+// receive no emails.
+func (s *Service) Preferences(ctx context.Context, userID int32) (db.NotificationPreference, error) {
+	prefs, err := s.queries.GetNotificationPreferences(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.NotificationPreference{UserID: userID}, nil
+	}
+	return prefs, err
+}
+
+func (s *Service) SavePreferences(ctx context.Context, userID int32, scanFinished, newBroken, weeklyDigest bool) (db.NotificationPreference, error) {
+	return s.queries.UpsertNotificationPreferences(ctx, db.UpsertNotificationPreferencesParams{
+		UserID:       userID,
+		ScanFinished: scanFinished,
+		NewBroken:    newBroken,
+		WeeklyDigest: weeklyDigest,
+	})
+}
+
+// Address returns the email address notifications are sent to.
+func (s *Service) Address(ctx context.Context, userID int32) (string, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Email, nil
+}
+
+func (s *Service) SendTest(ctx context.Context, userID int32) error {
+	address, err := s.Address(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, address, "Dead Link Scanner test email", emailui.TestEmail())
+}
+
+// HandleEvent emails the scan's owner according to their preferences. It is
+// registered as a scanner listener.
+func (s *Service) HandleEvent(e scanner.Event) {
+	if !s.Enabled() {
+		return
+	}
+	ctx := context.Background()
+
+	prefs, err := s.Preferences(ctx, e.UserID)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %d: %v", e.UserID, err)
+		return
+	}
+
+	var subject string
+	var body templ.Component
+	model := scanEmail(e, s.baseURL)
+	switch {
+	case e.Type == scanner.EventScanFinished && prefs.ScanFinished:
+		subject = fmt.Sprintf("Scan finished: %d broken links on %s", model.Broken, model.StartURL)
+		body = emailui.ScanFinished(model)
+	case e.Type == scanner.EventScanFailed && prefs.ScanFinished:
+		subject = "Scan failed: " + model.StartURL
+		body = emailui.ScanFailed(model)
+	case e.Type == scanner.EventNewlyBroken && prefs.NewBroken:
+		subject = fmt.Sprintf("%d new broken links on %s", len(model.NewlyBroken), model.StartURL)
+		body = emailui.NewlyBroken(model)
+	default:
+		return
+	}
+
+	address, err := s.Address(ctx, e.UserID)
+	if err != nil {
+		log.Printf("Failed to load user %d: %v", e.UserID, err)
+		return
+	}
+	if err := s.send(ctx, address, subject, body); err != nil {
+		log.Printf("Failed to email user %d about %s: %v", e.UserID, e.Type, err)
+	}
+}
+
+// RunDigests sends weekly digests until ctx is cancelled.
+func (s *Service) RunDigests(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if now := time.Now(); now.Weekday() == digestWeekday && now.Hour() >= digestHour {
+			s.sendDigests(ctx, now)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) sendDigests(ctx context.Context, now time.Time) {
+	cutoff := sql.NullTime{Time: now.Add(-digestInterval).UTC(), Valid: true}
+	recipients, err := s.queries.ListDigestRecipients(ctx, cutoff)
+	if err != nil {
+		log.Printf("Failed to list digest recipients: %v", err)
+		return
+	}
+
+	for _, r := range recipients {
+		if err := s.SendDigest(ctx, r.ID, r.Email, now); err != nil {
+			log.Printf("Failed to send digest to user %d: %v", r.ID, err)
+			continue
+		}
+		err := s.queries.MarkDigestSent(ctx, db.MarkDigestSentParams{
+			UserID:       r.ID,
+			DigestSentAt: sql.NullTime{Time: now.UTC(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("Failed to mark digest sent for user %d: %v", r.ID, err)
+		}
+	}
+}
+
+// SendDigest emails a summary of the latest scan of every site the user
+// scanned during the week before now.
+func (s *Service) SendDigest(ctx context.Context, userID int32, to string, now time.Time) error {
+	since := now.AddDate(0, 0, -7)
+	scans, err := s.queries.ListScansByUserSince(ctx, db.ListScansByUserSinceParams{
+		UserID:    userID,
+		StartedAt: since,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Scans are newest first, so the first scan of a site is its latest.
+	var sites []emailui.DigestSite
+	index := make(map[string]int)
+	for _, scan := range scans {
+		if i, ok := index[scan.StartUrl]; ok {
+			sites[i].Scans++
+			continue
+		}
+
+		summary, err := s.scanner.SummarizeScan(ctx, scan)
+		if err != nil {
+			return err
+		}
+		index[scan.StartUrl] = len(sites)
+		sites = append(sites, emailui.DigestSite{
+			StartURL:  scan.StartUrl,
+			Scans:     1,
+			Total:     summary.Total,
+			Broken:    summary.Broken,
+			TopBroken: links(summary.TopBroken),
+		})
+	}
+
+	period := since.Format("Jan 2") + " to " + now.Format("Jan 2, 2006")
+	return s.send(ctx, to, "Weekly dead link digest", emailui.Digest(sites, period, s.baseURL+"/scan"))
+}
+
+func (s *Service) send(ctx context.Context, to, subject string, body templ.Component) error {
+	var buf bytes.Buffer
+	if err := body.Render(ctx, &buf); err != nil {
+		return err
+	}
+	return s.mailer.Send(to, subject, buf.String())
+}
+
+func scanEmail(e scanner.Event, baseURL string) emailui.ScanEmail {
+	model := emailui.ScanEmail{
+		StartURL:    e.Summary.StartURL,
+		Total:       e.Summary.Total,
+		Broken:      e.Summary.Broken,
+		TopBroken:   links(e.Summary.TopBroken),
+		NewlyBroken: links(e.Summary.NewlyBroken),
+		Error:       e.Error,
+	}
+	if e.Summary.ScanID != 0 {
+		model.ReportURL = baseURL + "/scans/compare?target=" + strconv.Itoa(int(e.Summary.ScanID))
+	}
+	return model
+}
+
+func links(summaryLinks []scanner.SummaryLink) []emailui.Link {
+	var out []emailui.Link
+	for _, l := range summaryLinks {
+		status := string(l.Category)
+		if l.StatusCode > 0 {
+			status = strconv.Itoa(l.StatusCode) + " " + status
+		}
+		out = append(out, emailui.Link{URL: l.URL, Status: status})
+	}
+	return out
+}
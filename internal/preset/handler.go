@@ -0,0 +1,186 @@
+package preset
+
+import (
+	"errors"
+	"go-deadlink-scanner/internal/siteconfig"
+	presetui "go-deadlink-scanner/internal/templates/preset"
+	"go-deadlink-scanner/internal/ui"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxConfigSize bounds uploaded config files.
+const maxConfigSize = 256 * 1024
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) PresetsPage(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	rows, err := h.rows(c, userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading presets: " + err.Error())
+	}
+
+	return ui.RenderComponent(c, presetui.PresetsPage(rows))
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+// Upload stores a .deadlink.yml sent as a "config" file, a "source" form
+// field or the raw request body.
+func (h *Handler) Upload(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	source, err := readSource(c)
+	if err != nil {
+		return h.fail(c, userId, fiber.StatusBadRequest, []string{err.Error()})
+	}
+
+	if _, err := h.service.Save(c.Context(), userId, c.FormValue("site"), source); err != nil {
+		var invalid *siteconfig.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			return h.fail(c, userId, fiber.StatusBadRequest, invalid.Problems)
+		case errors.Is(err, ErrInvalidPreset):
+			return h.fail(c, userId, fiber.StatusBadRequest, []string{err.Error()})
+		default:
+			return h.fail(c, userId, fiber.StatusInternalServerError, []string{err.Error()})
+		}
+	}
+
+	if !ui.IsHX(c) {
+		c.Status(fiber.StatusCreated)
+	}
+	return h.respond(c, userId, nil)
+}
+
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid preset id",
+		})
+	}
+
+	if err := h.service.Delete(c.Context(), userId, int32(id)); err != nil {
+		return h.fail(c, userId, fiber.StatusInternalServerError, []string{err.Error()})
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+func readSource(c *fiber.Ctx) ([]byte, error) {
+	if file, err := c.FormFile("config"); err == nil {
+		if file.Size > maxConfigSize {
+			return nil, errors.New("config file is too large")
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+	if source := c.FormValue("source"); source != "" {
+		return []byte(source), nil
+	}
+	if len(c.Body()) > maxConfigSize {
+		return nil, errors.New("config file is too large")
+	}
+	return c.Body(), nil
+}
+
+// fail reports the problems as JSON, or re-renders the preset list with them
+// for htmx requests, which do not swap error responses.
+func (h *Handler) fail(c *fiber.Ctx, userID int32, status int, problems []string) error {
+	if ui.IsHX(c) {
+		return h.respond(c, userID, problems)
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":    problems[0],
+		"problems": problems,
+	})
+}
+
+func (h *Handler) respond(c *fiber.Ctx, userID int32, errs []string) error {
+	if ui.IsHX(c) {
+		rows, err := h.rows(c, userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Error loading presets: " + err.Error())
+		}
+		return ui.RenderComponent(c, presetui.PresetList(rows, errs))
+	}
+
+	presets, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	items := make([]fiber.Map, 0, len(presets))
+	for _, p := range presets {
+		items = append(items, fiber.Map{
+			"id":         p.ID,
+			"site":       p.Site,
+			"source":     p.Source,
+			"created_at": p.CreatedAt,
+			"updated_at": p.UpdatedAt,
+		})
+	}
+	return c.JSON(fiber.Map{"presets": items})
+}
+
+func (h *Handler) rows(c *fiber.Ctx, userID int32) ([]presetui.PresetRow, error) {
+	presets, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []presetui.PresetRow
+	for _, p := range presets {
+		rows = append(rows, presetui.PresetRow{
+			ID:        p.ID,
+			Site:      p.Site,
+			Source:    p.Source,
+			UpdatedAt: p.UpdatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return rows, nil
+}
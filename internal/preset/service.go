@@ -0,0 +1,67 @@
+package preset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/internal/siteconfig"
+	"strings"
+)
+
+var ErrInvalidPreset = errors.New("invalid preset")
+
+// Service stores .deadlink.yml files as per-site scan presets. The scanner
+// applies a preset's rules to scans of its site; the auth section only
+// applies to the CLI, as the server never reads its own environment on a
+// user's behalf.
+type Service struct {
+	queries *db.Queries
+}
+
+func NewService(queries *db.Queries) *Service {
+	return &Service{queries: queries}
+}
+
+// Save validates a config file and stores it for site, or for the site of
+// its first start URL when site is empty. An existing preset is replaced.
+func (s *Service) Save(ctx context.Context, userID int32, site string, source []byte) (db.SitePreset, error) {
+	cfg, err := siteconfig.Parse(source)
+	if err != nil {
+		return db.SitePreset{}, err
+	}
+
+	if site = strings.TrimSpace(site); site != "" {
+		if !strings.Contains(site, "://") {
+			site = "http://" + site
+		}
+		site = scanner.SiteOf(site)
+	} else {
+		site = cfg.Site()
+	}
+	if site == "" {
+		return db.SitePreset{}, fmt.Errorf("%w: name the site or list start_urls in the file", ErrInvalidPreset)
+	}
+
+	rules, err := json.Marshal(cfg.Rules())
+	if err != nil {
+		return db.SitePreset{}, err
+	}
+
+	return s.queries.UpsertSitePreset(ctx, db.UpsertSitePresetParams{
+		UserID: userID,
+		Site:   site,
+		Source: string(source),
+		Rules:  string(rules),
+	})
+}
+
+func (s *Service) List(ctx context.Context, userID int32) ([]db.SitePreset, error) {
+	return s.queries.ListSitePresetsByUser(ctx, userID)
+}
+
+func (s *Service) Delete(ctx context.Context, userID, id int32) error {
+	return s.queries.DeleteSitePreset(ctx, db.DeleteSitePresetParams{ID: id, UserID: userID})
+}
@@ -2,36 +2,98 @@ package routes
 
 import (
 	"go-deadlink-scanner/internal/auth"
+	"go-deadlink-scanner/internal/email"
+	"go-deadlink-scanner/internal/preset"
 	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/internal/schedule"
 	"go-deadlink-scanner/internal/user"
+	"go-deadlink-scanner/internal/webhook"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type Router struct {
-	app            *fiber.App
-	userHandler    *user.Handler
-	scannerHandler *scanner.Handler
-	authMiddleware *auth.Middleware
+	app             *fiber.App
+	userHandler     *user.Handler
+	scannerHandler  *scanner.Handler
+	scheduleHandler *schedule.Handler
+	webhookHandler  *webhook.Handler
+	emailHandler    *email.Handler
+	presetHandler   *preset.Handler
+	authMiddleware  *auth.Middleware
 }
 
-func New(app *fiber.App, uh *user.Handler, sh *scanner.Handler, am *auth.Middleware) *Router {
-	return &Router{app: app, userHandler: uh, scannerHandler: sh, authMiddleware: am}
+func New(app *fiber.App, uh *user.Handler, sh *scanner.Handler, sch *schedule.Handler, wh *webhook.Handler, eh *email.Handler, ph *preset.Handler, am *auth.Middleware) *Router {
+	return &Router{app: app, userHandler: uh, scannerHandler: sh, scheduleHandler: sch, webhookHandler: wh, emailHandler: eh, presetHandler: ph, authMiddleware: am}
 }
 
+// Register mounts the routes of every handler that was provided; a server
+// running without a database only has the scanner.
 func (r *Router) Register() {
-	r.app.Get("/register", r.userHandler.RegisterPage)
-	r.app.Get("/login", r.userHandler.LoginPage)
+	if r.userHandler != nil {
+		r.app.Get("/register", r.userHandler.RegisterPage)
+		r.app.Get("/login", r.userHandler.LoginPage)
+		r.app.Post("/logout", r.userHandler.Logout)
 
-	r.app.Get("/scan", r.authMiddleware.RequireAuth(), r.scannerHandler.ScanPage)
+		userGroup := r.app.Group("/api/user")
+		userGroup.Post("/register", r.userHandler.Register)
+		userGroup.Post("/login", r.userHandler.Login)
+		userGroup.Post("/logout", r.authMiddleware.RequireAuth(), r.userHandler.Logout)
+	}
 
-	r.app.Post("/logout", r.userHandler.Logout)
+	if r.scannerHandler != nil {
+		r.app.Get("/scan", r.authMiddleware.RequireAuth(), r.scannerHandler.ScanPage)
+		r.app.Get("/scans/compare", r.authMiddleware.RequireAuth(), r.scannerHandler.ComparePage)
 
-	userGroup := r.app.Group("/api/user")
-	userGroup.Post("/register", r.userHandler.Register)
-	userGroup.Post("/login", r.userHandler.Login)
-	userGroup.Post("/logout", r.authMiddleware.RequireAuth(), r.userHandler.Logout)
+		scannerGroup := r.app.Group("/api/scanner", r.authMiddleware.RequireAuth())
+		scannerGroup.Post("/scan", r.scannerHandler.StartScan)
+		scannerGroup.Post("/list", r.scannerHandler.CheckList)
+		scannerGroup.Post("/redirects", r.scannerHandler.VerifyRedirects)
+		scannerGroup.Get("/results", r.scannerHandler.ListResults)
+		scannerGroup.Get("/scans", r.scannerHandler.ListScans)
+		scannerGroup.Get("/scans/:id/export", r.scannerHandler.ExportScan)
+		scannerGroup.Post("/scans/:id/recheck", r.scannerHandler.RecheckBroken)
+		scannerGroup.Get("/scans/:id/baseline", r.scannerHandler.DownloadBaseline)
+		scannerGroup.Post("/scans/:id/baseline/check", r.scannerHandler.CheckBaseline)
+		scannerGroup.Get("/diff", r.scannerHandler.CompareScans)
+	}
 
-	scannerGroup := r.app.Group("/api/scanner", r.authMiddleware.RequireAuth())
-	scannerGroup.Post("/scan", r.scannerHandler.StartScan)
+	if r.scheduleHandler != nil {
+		r.app.Get("/schedules", r.authMiddleware.RequireAuth(), r.scheduleHandler.SchedulesPage)
+
+		scheduleGroup := r.app.Group("/api/schedules", r.authMiddleware.RequireAuth())
+		scheduleGroup.Get("/", r.scheduleHandler.List)
+		scheduleGroup.Post("/", r.scheduleHandler.Create)
+		scheduleGroup.Post("/:id/enabled", r.scheduleHandler.SetEnabled)
+		scheduleGroup.Delete("/:id", r.scheduleHandler.Delete)
+	}
+
+	if r.webhookHandler != nil {
+		r.app.Get("/webhooks", r.authMiddleware.RequireAuth(), r.webhookHandler.WebhooksPage)
+
+		webhookGroup := r.app.Group("/api/webhooks", r.authMiddleware.RequireAuth())
+		webhookGroup.Get("/", r.webhookHandler.List)
+		webhookGroup.Post("/", r.webhookHandler.Create)
+		webhookGroup.Delete("/:id", r.webhookHandler.Delete)
+		webhookGroup.Post("/:id/test", r.webhookHandler.SendTest)
+		webhookGroup.Get("/:id/deliveries", r.webhookHandler.Deliveries)
+	}
+
+	if r.emailHandler != nil {
+		r.app.Get("/notifications", r.authMiddleware.RequireAuth(), r.emailHandler.NotificationsPage)
+
+		notificationGroup := r.app.Group("/api/notifications", r.authMiddleware.RequireAuth())
+		notificationGroup.Get("/", r.emailHandler.GetPreferences)
+		notificationGroup.Post("/", r.emailHandler.SavePreferences)
+		notificationGroup.Post("/test", r.emailHandler.SendTest)
+	}
+
+	if r.presetHandler != nil {
+		r.app.Get("/presets", r.authMiddleware.RequireAuth(), r.presetHandler.PresetsPage)
+
+		presetGroup := r.app.Group("/api/presets", r.authMiddleware.RequireAuth())
+		presetGroup.Get("/", r.presetHandler.List)
+		presetGroup.Post("/", r.presetHandler.Upload)
+		presetGroup.Delete("/:id", r.presetHandler.Delete)
+	}
 }
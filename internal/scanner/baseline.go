@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/pkg/deadlink"
+	"io"
+	"sort"
+	"time"
+)
+
+const baselineVersion = 1
+
+var ErrInvalidBaseline = errors.New("invalid baseline")
+
+// Baseline lists broken links that are known and accepted, so checks only
+// fail on regressions. Links are matched by normalized URL.
+type Baseline struct {
+	Version     int            `json:"version"`
+	StartURL    string         `json:"start_url,omitempty"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Links       []BaselineLink `json:"links"`
+}
+
+type BaselineLink struct {
+	URL        string `json:"url"`
+	Category   string `json:"category,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	// Note is free text for whoever accepted the link, e.g. a ticket.
+	Note string `json:"note,omitempty"`
+}
+
+// BaselineCheck is the outcome of checking results against a baseline.
+type BaselineCheck struct {
+	// New are broken links missing from the baseline.
+	New []db.Result
+	// Accepted are broken links listed in the baseline.
+	Accepted []db.Result
+	// Fixed are baseline links that are no longer broken or no longer
+	// linked; they can be dropped by regenerating the baseline.
+	Fixed []BaselineLink
+}
+
+// NewBaseline accepts every broken link of a scan.
+func NewBaseline(startURL string, results []db.Result, now time.Time) *Baseline {
+	b := &Baseline{Version: baselineVersion, StartURL: startURL, GeneratedAt: now.UTC(), Links: []BaselineLink{}}
+	for _, r := range results {
+		if IsBrokenResult(r) {
+			b.Links = append(b.Links, BaselineLink{URL: r.LinkUrl, Category: r.Category, StatusCode: int(r.StatusCode)})
+		}
+	}
+	sort.Slice(b.Links, func(i, j int) bool { return b.Links[i].URL < b.Links[j].URL })
+	return b
+}
+
+// ReadBaseline parses a baseline file.
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var b Baseline
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBaseline, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBaseline, b.Version)
+	}
+	for i, l := range b.Links {
+		if l.URL == "" {
+			return nil, fmt.Errorf("%w: link %d has no url", ErrInvalidBaseline, i+1)
+		}
+	}
+	return &b, nil
+}
+
+// Write stores the baseline as indented JSON, which keeps diffs of checked
+// in baselines readable.
+func (b *Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// Check splits the broken results into new and accepted ones.
+func (b *Baseline) Check(results []db.Result) BaselineCheck {
+	accepted := make(map[string]bool, len(b.Links))
+	for _, l := range b.Links {
+		accepted[deadlink.NormalizeURL(l.URL)] = true
+	}
+
+	var check BaselineCheck
+	stillBroken := make(map[string]bool)
+	for _, r := range results {
+		if !IsBrokenResult(r) {
+			continue
+		}
+		key := deadlink.NormalizeURL(r.LinkUrl)
+		if accepted[key] {
+			check.Accepted = append(check.Accepted, r)
+			stillBroken[key] = true
+		} else {
+			check.New = append(check.New, r)
+		}
+	}
+
+	for _, l := range b.Links {
+		if !stillBroken[deadlink.NormalizeURL(l.URL)] {
+			check.Fixed = append(check.Fixed, l)
+		}
+	}
+	return check
+}
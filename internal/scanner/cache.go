@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"log"
+	"sync"
+	"time"
+)
+
+// linkCache is the crawler cache shared by all scans. Entries live in
+// memory and in Postgres so they survive restarts and are shared between
+// server instances.
+type linkCache struct {
+	queries *db.Queries
+	ttl     time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result    ScanResult
+	checkedAt time.Time
+}
+
+func newLinkCache(queries *db.Queries, ttl time.Duration) *linkCache {
+	return &linkCache{
+		queries: queries,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *linkCache) enabled() bool {
+	return c != nil && c.ttl > 0
+}
+
+func (c *linkCache) Get(ctx context.Context, key string) (*ScanResult, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && time.Since(entry.checkedAt) < c.ttl {
+		result := entry.result
+		return &result, true
+	}
+
+	if c.queries == nil {
+		return nil, false
+	}
+
+	row, err := c.queries.GetLinkCache(ctx, db.GetLinkCacheParams{
+		Url:       key,
+		CheckedAt: time.Now().Add(-c.ttl),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to read link cache for %s: %v", key, err)
+		}
+		return nil, false
+	}
+
+	result := ScanResult{
+		Category:    Category(row.Category),
+		StatusCode:  int(row.StatusCode),
+		ContentType: row.ContentType,
+		Error:       row.ErrorDetail,
+		Warning:     row.Warning,
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{result: result, checkedAt: row.CheckedAt}
+	c.mu.Unlock()
+
+	return &result, true
+}
+
+// Put keeps the outcome of a check. Timings and the remote address belong to
+// the scan that made the request, so they are not cached, as in Postgres.
+func (c *linkCache) Put(ctx context.Context, key string, result *ScanResult) {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{result: ScanResult{
+		Category:    result.Category,
+		StatusCode:  result.StatusCode,
+		ContentType: result.ContentType,
+		Error:       result.Error,
+		Warning:     result.Warning,
+	}, checkedAt: now}
+	c.mu.Unlock()
+
+	if c.queries == nil {
+		return
+	}
+
+	err := c.queries.UpsertLinkCache(ctx, db.UpsertLinkCacheParams{
+		Url:         key,
+		Category:    string(result.Category),
+		StatusCode:  int32(result.StatusCode),
+		ContentType: result.ContentType,
+		ErrorDetail: result.Error,
+		Warning:     result.Warning,
+		CheckedAt:   now,
+	})
+	if err != nil {
+		log.Printf("Failed to write link cache for %s: %v", key, err)
+	}
+}
+
+// prune drops entries that are past the TTL, in memory and in Postgres.
+func (c *linkCache) prune(ctx context.Context) {
+	cutoff := time.Now().Add(-c.ttl)
+
+	c.mu.Lock()
+	for key, entry := range c.entries {
+		if entry.checkedAt.Before(cutoff) {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.queries == nil {
+		return
+	}
+	if err := c.queries.DeleteExpiredLinkCache(ctx, cutoff); err != nil {
+		log.Printf("Failed to prune link cache: %v", err)
+	}
+}
+
+// RunCachePruning drops expired link cache entries once per TTL until ctx is
+// cancelled, so a long-running server does not keep every link it has ever
+// checked.
+func (s *Service) RunCachePruning(ctx context.Context) {
+	if !s.cache.enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(s.cache.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cache.prune(ctx)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/pkg/deadlink"
+	"slices"
+)
+
+// Category, ScanResult and Timing are the crawler's types. The crawler's
+// categories are repeated here so callers of the scanner need not import
+// both.
+type (
+	Category   = deadlink.Category
+	ScanResult = deadlink.Result
+	Timing     = deadlink.Timing
+)
+
+const (
+	CategoryOK                = deadlink.CategoryOK
+	CategoryRedirect          = deadlink.CategoryRedirect
+	CategoryClientError       = deadlink.CategoryClientError
+	CategoryServerError       = deadlink.CategoryServerError
+	CategoryTimeout           = deadlink.CategoryTimeout
+	CategoryDNSFailure        = deadlink.CategoryDNSFailure
+	CategoryConnectionRefused = deadlink.CategoryConnectionRefused
+	CategoryConnectionReset   = deadlink.CategoryConnectionReset
+	CategoryNetworkError      = deadlink.CategoryNetworkError
+	CategoryTLSError          = deadlink.CategoryTLSError
+	CategoryTooManyRedirects  = deadlink.CategoryTooManyRedirects
+	CategoryInvalidURL        = deadlink.CategoryInvalidURL
+	CategorySkipped           = deadlink.CategorySkipped
+)
+
+// Categories the scanner adds to the crawler's.
+const (
+	// CategoryMissingFile and CategoryMissingAnchor are found by offline
+	// scans of a site on disk.
+	CategoryMissingFile   Category = "missing_file"
+	CategoryMissingAnchor Category = "missing_anchor"
+	// CategoryWrongRedirect is an old URL of a redirect map that does not
+	// land on its expected new URL.
+	CategoryWrongRedirect Category = "wrong_redirect"
+	// CategoryRemoved is a broken link that a recheck no longer found on
+	// any of the pages it was linked from.
+	CategoryRemoved Category = "removed"
+	// CategoryIgnored is a failure that the scan rules ignore.
+	CategoryIgnored Category = "ignored"
+)
+
+var Categories = append(slices.Clone(deadlink.Categories),
+	CategoryMissingFile,
+	CategoryMissingAnchor,
+	CategoryWrongRedirect,
+	CategoryRemoved,
+	CategoryIgnored,
+)
+
+func ParseCategory(s string) (Category, bool) {
+	for _, c := range Categories {
+		if string(c) == s {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// IsBroken reports whether links in this category should be treated as
+// dead. Use it rather than Category.IsBroken, which does not know removed
+// and ignored links.
+func IsBroken(c Category) bool {
+	switch c {
+	case CategoryRemoved, CategoryIgnored:
+		return false
+	default:
+		return c.IsBroken()
+	}
+}
+
+// IsBrokenResult reports whether a stored result is a dead link: its
+// category is broken and the scan rules did not report it as a warning.
+func IsBrokenResult(r db.Result) bool {
+	return IsBroken(Category(r.Category)) && Severity(r.Severity) != SeverityWarning
+}
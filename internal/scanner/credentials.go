@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Credentials are attached to requests for in-scope hosts only, i.e. hosts
+// that the crawler treats as internal to the scanned site.
+type Credentials struct {
+	BasicUser   string            `json:"basic_user,omitempty"`
+	BasicPass   string            `json:"basic_pass,omitempty"`
+	BearerToken string            `json:"bearer_token,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Cookies     map[string]string `json:"cookies,omitempty"`
+	Login       *LoginStep        `json:"login,omitempty"`
+}
+
+func (c *Credentials) IsEmpty() bool {
+	return c == nil || (c.BasicUser == "" && c.BasicPass == "" && c.BearerToken == "" &&
+		len(c.Headers) == 0 && len(c.Cookies) == 0 && c.Login == nil)
+}
+
+func (c *Credentials) apply(req *http.Request) {
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	if c.BasicUser != "" || c.BasicPass != "" {
+		req.SetBasicAuth(c.BasicUser, c.BasicPass)
+	} else if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	}
+	for name, value := range c.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+}
+
+// strip removes everything apply may have added. It is used when a redirect
+// leaves the scanned site, since http.Client copies custom headers to the
+// next hop.
+func (c *Credentials) strip(req *http.Request) {
+	for name := range c.Headers {
+		req.Header.Del(name)
+	}
+	req.Header.Del("Authorization")
+	req.Header.Del("Cookie")
+}
+
+type credentialScope struct {
+	creds *Credentials
+	scope *url.URL
+}
+
+type credentialScopeKey struct{}
+
+// withCredentials applies creds to req when its host is in scope and records
+// the scope in the request context so redirects can be re-checked.
+func withCredentials(req *http.Request, creds *Credentials, scope *url.URL) *http.Request {
+	if creds.IsEmpty() || scope == nil {
+		return req
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), credentialScopeKey{}, &credentialScope{creds: creds, scope: scope}))
+	if req.URL.Host == scope.Host {
+		creds.apply(req)
+	}
+	return req
+}
+
+// scopeRedirect re-applies or strips credentials for a redirected request
+// depending on whether the new host is still in scope.
+func scopeRedirect(req *http.Request) {
+	cs, ok := req.Context().Value(credentialScopeKey{}).(*credentialScope)
+	if !ok {
+		return
+	}
+
+	cs.creds.strip(req)
+	if req.URL.Host == cs.scope.Host {
+		cs.creds.apply(req)
+	}
+}
+
+// ParseHeaderLines parses "Name: value" lines, ignoring blank lines.
+func ParseHeaderLines(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header line %q, expected \"Name: value\"", line)
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// ParseCookieLines parses "name=value" pairs separated by newlines or
+// semicolons, as copied from a browser's Cookie header.
+func ParseCookieLines(raw string) (map[string]string, error) {
+	cookies := make(map[string]string)
+	for _, line := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ';' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid cookie %q, expected \"name=value\"", line)
+		}
+		cookies[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return cookies, nil
+}
@@ -0,0 +1,183 @@
+package scanner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type DiffStatus string
+
+const (
+	DiffNewlyBroken DiffStatus = "newly_broken"
+	DiffFixed       DiffStatus = "fixed"
+	DiffStillBroken DiffStatus = "still_broken"
+	DiffNew         DiffStatus = "new"
+	DiffRemoved     DiffStatus = "removed"
+)
+
+// DiffStatuses lists the statuses in display order.
+var DiffStatuses = []DiffStatus{DiffNewlyBroken, DiffFixed, DiffStillBroken, DiffNew, DiffRemoved}
+
+var (
+	ErrScanNotFound  = errors.New("scan not found")
+	ErrDifferentSite = errors.New("scans are of different sites")
+)
+
+// DiffEntry is one link that differs between two scans, or is broken in
+// both. Before is nil for links that only appear in the newer scan, After
+// for links that disappeared.
+type DiffEntry struct {
+	URL    string
+	Status DiffStatus
+	Before *db.Result
+	After  *db.Result
+}
+
+type ScanDiff struct {
+	Base    db.Scan
+	Target  db.Scan
+	Entries []DiffEntry
+	// Unchanged counts links that are fine in both scans.
+	Unchanged int
+}
+
+// Count returns the number of entries with the given status.
+func (d *ScanDiff) Count(status DiffStatus) int {
+	n := 0
+	for _, e := range d.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ListScans returns the user's most recent scans, newest first.
+func (s *Service) ListScans(ctx context.Context, userID int32, limit int32) ([]db.Scan, error) {
+	return s.store.ListScans(ctx, userID, limit)
+}
+
+// CompareScans diffs the results of two scans of the same site owned by the
+// user. base is the older scan, target the newer one.
+func (s *Service) CompareScans(ctx context.Context, userID, baseID, targetID int32) (*ScanDiff, error) {
+	base, err := s.userScan(ctx, userID, baseID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.userScan(ctx, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if SiteOf(base.StartUrl) != SiteOf(target.StartUrl) {
+		return nil, fmt.Errorf("%w: %s and %s", ErrDifferentSite, base.StartUrl, target.StartUrl)
+	}
+
+	before, err := s.store.ListScanResults(ctx, base.ID)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.store.ListScanResults(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffResults(before, after)
+	diff.Base = base
+	diff.Target = target
+	return diff, nil
+}
+
+// PreviousScan returns the latest finished scan of the same site that
+// started before scan, for comparing against the most recent run.
+func (s *Service) PreviousScan(ctx context.Context, userID int32, scan db.Scan) (db.Scan, bool, error) {
+	prev, err := s.store.PreviousScan(ctx, db.GetPreviousScanParams{
+		UserID:    userID,
+		Site:      SiteOf(scan.StartUrl),
+		StartedAt: scan.StartedAt,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Scan{}, false, nil
+	}
+	if err != nil {
+		return db.Scan{}, false, err
+	}
+	return prev, true, nil
+}
+
+func (s *Service) userScan(ctx context.Context, userID, scanID int32) (db.Scan, error) {
+	scan, err := s.store.GetScan(ctx, scanID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && scan.UserID != userID) {
+		return db.Scan{}, fmt.Errorf("%w: %d", ErrScanNotFound, scanID)
+	}
+	return scan, err
+}
+
+// SiteOf identifies the site of a start URL by its lowercased host. Start
+// URLs without a host, such as URL list labels, are their own site.
+func SiteOf(startURL string) string {
+	u, err := url.Parse(startURL)
+	if err != nil || u.Host == "" {
+		return startURL
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// diffResults classifies links by URL. A link that only appears in the newer
+// scan counts as newly broken when it is broken and as new otherwise.
+func diffResults(before, after []db.Result) *ScanDiff {
+	diff := &ScanDiff{}
+
+	old := make(map[string]*db.Result, len(before))
+	for i := range before {
+		old[before[i].LinkUrl] = &before[i]
+	}
+
+	for i := range after {
+		a := &after[i]
+		b, existed := old[a.LinkUrl]
+		delete(old, a.LinkUrl)
+
+		nowBroken := IsBrokenResult(*a)
+		var status DiffStatus
+		switch {
+		case !existed && nowBroken:
+			status = DiffNewlyBroken
+		case !existed:
+			status = DiffNew
+		case IsBrokenResult(*b) && nowBroken:
+			status = DiffStillBroken
+		case IsBrokenResult(*b):
+			status = DiffFixed
+		case nowBroken:
+			status = DiffNewlyBroken
+		default:
+			diff.Unchanged++
+			continue
+		}
+		diff.Entries = append(diff.Entries, DiffEntry{URL: a.LinkUrl, Status: status, Before: b, After: a})
+	}
+
+	for linkURL, b := range old {
+		diff.Entries = append(diff.Entries, DiffEntry{URL: linkURL, Status: DiffRemoved, Before: b})
+	}
+
+	rank := make(map[DiffStatus]int, len(DiffStatuses))
+	for i, status := range DiffStatuses {
+		rank[status] = i
+	}
+	sort.Slice(diff.Entries, func(i, j int) bool {
+		ei, ej := diff.Entries[i], diff.Entries[j]
+		if ei.Status != ej.Status {
+			return rank[ei.Status] < rank[ej.Status]
+		}
+		return ei.URL < ej.URL
+	})
+
+	return diff
+}
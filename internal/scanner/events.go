@@ -0,0 +1,176 @@
+package scanner
+
+import (
+	"context"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventScanFinished EventType = "scan.finished"
+	EventScanFailed   EventType = "scan.failed"
+	EventNewlyBroken  EventType = "scan.newly_broken"
+)
+
+// EventTypes lists the events listeners can subscribe to.
+var EventTypes = []EventType{EventScanFinished, EventScanFailed, EventNewlyBroken}
+
+// topBrokenLimit caps the broken links listed in a summary.
+const topBrokenLimit = 10
+
+// Event is published after every scan. Summary is always set; for failed
+// scans it only carries the start URL and Error explains the failure.
+type Event struct {
+	Type    EventType
+	UserID  int32
+	Summary *Summary
+	Error   string
+	At      time.Time
+}
+
+// Summary condenses a scan for notifications.
+type Summary struct {
+	ScanID      int32            `json:"scan_id"`
+	StartURL    string           `json:"start_url"`
+	Total       int              `json:"total"`
+	Broken      int              `json:"broken"`
+	Categories  map[Category]int `json:"categories"`
+	TopBroken   []SummaryLink    `json:"top_broken"`
+	NewlyBroken []SummaryLink    `json:"newly_broken"`
+}
+
+type SummaryLink struct {
+	URL        string   `json:"url"`
+	Category   Category `json:"category"`
+	StatusCode int      `json:"status_code,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Listener receives scan events. Listeners run in their own goroutine.
+type Listener func(Event)
+
+type listeners struct {
+	mu   sync.RWMutex
+	list []Listener
+}
+
+// Subscribe registers l for every scan event.
+func (s *Service) Subscribe(l Listener) {
+	s.listeners.mu.Lock()
+	s.listeners.list = append(s.listeners.list, l)
+	s.listeners.mu.Unlock()
+}
+
+func (s *Service) publish(e Event) {
+	s.listeners.mu.RLock()
+	defer s.listeners.mu.RUnlock()
+	for _, l := range s.listeners.list {
+		go l(e)
+	}
+}
+
+// notify publishes the outcome of a scan. Newly broken links are found by
+// comparing with the previous scan of the same site.
+func (s *Service) notify(userID int32, startURL string, report *Report, scanErr error) {
+	now := time.Now()
+
+	if scanErr != nil {
+		s.publish(Event{
+			Type:    EventScanFailed,
+			UserID:  userID,
+			Summary: &Summary{StartURL: startURL, Categories: map[Category]int{}},
+			Error:   scanErr.Error(),
+			At:      now,
+		})
+		return
+	}
+
+	summary := Summarize(report)
+	summary.NewlyBroken = s.newlyBroken(context.Background(), userID, report.ScanID)
+
+	s.publish(Event{Type: EventScanFinished, UserID: userID, Summary: summary, At: now})
+	if len(summary.NewlyBroken) > 0 {
+		s.publish(Event{Type: EventNewlyBroken, UserID: userID, Summary: summary, At: now})
+	}
+}
+
+func (s *Service) newlyBroken(ctx context.Context, userID, scanID int32) []SummaryLink {
+	if scanID == 0 {
+		return nil
+	}
+
+	scan, err := s.store.GetScan(ctx, scanID)
+	if err != nil {
+		log.Printf("Failed to load scan %d: %v", scanID, err)
+		return nil
+	}
+	prev, found, err := s.PreviousScan(ctx, userID, scan)
+	if err != nil || !found {
+		return nil
+	}
+
+	diff, err := s.CompareScans(ctx, userID, prev.ID, scan.ID)
+	if err != nil {
+		log.Printf("Failed to compare scan %d with %d: %v", scan.ID, prev.ID, err)
+		return nil
+	}
+
+	var links []SummaryLink
+	for _, e := range diff.Entries {
+		if e.Status == DiffNewlyBroken {
+			links = append(links, summaryLink(*e.After))
+		}
+	}
+	return links
+}
+
+// Summarize counts a report's results by category and lists the first
+// broken links, ordered by URL.
+func Summarize(report *Report) *Summary {
+	summary := &Summary{
+		ScanID:     report.ScanID,
+		StartURL:   report.StartURL,
+		Total:      len(report.Results),
+		Categories: make(map[Category]int),
+	}
+
+	var broken []SummaryLink
+	for _, r := range report.Results {
+		summary.Categories[Category(r.Category)]++
+		if IsBrokenResult(r) {
+			broken = append(broken, summaryLink(r))
+		}
+	}
+	summary.Broken = len(broken)
+
+	sort.Slice(broken, func(i, j int) bool { return broken[i].URL < broken[j].URL })
+	if len(broken) > topBrokenLimit {
+		broken = broken[:topBrokenLimit]
+	}
+	summary.TopBroken = broken
+
+	return summary
+}
+
+// SummarizeScan rebuilds the summary of a stored scan.
+func (s *Service) SummarizeScan(ctx context.Context, scan db.Scan) (*Summary, error) {
+	results, err := s.store.ListScanResults(ctx, scan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(&Report{ScanID: scan.ID, StartURL: scan.StartUrl, Results: results}), nil
+}
+
+func summaryLink(r db.Result) SummaryLink {
+	return SummaryLink{
+		URL:        r.LinkUrl,
+		Category:   Category(r.Category),
+		StatusCode: int(r.StatusCode),
+		Error:      r.ErrorDetail,
+	}
+}
@@ -0,0 +1,401 @@
+package scanner
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportCSV      ExportFormat = "csv"
+	ExportJSON     ExportFormat = "json"
+	ExportJUnit    ExportFormat = "junit"
+	ExportSARIF    ExportFormat = "sarif"
+	ExportMarkdown ExportFormat = "markdown"
+)
+
+var ExportFormats = []ExportFormat{ExportCSV, ExportJSON, ExportJUnit, ExportSARIF, ExportMarkdown}
+
+func ParseExportFormat(s string) (ExportFormat, bool) {
+	for _, f := range ExportFormats {
+		if string(f) == s {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportJUnit:
+		return "application/xml; charset=utf-8"
+	case ExportSARIF:
+		return "application/sarif+json"
+	case ExportMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file name extension, without the dot.
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportJUnit:
+		return "xml"
+	case ExportSARIF:
+		return "sarif"
+	case ExportMarkdown:
+		return "md"
+	default:
+		return string(f)
+	}
+}
+
+// ScanResults returns one of the user's scans with its results.
+func (s *Service) ScanResults(ctx context.Context, userID, scanID int32) (db.Scan, []db.Result, error) {
+	scan, err := s.userScan(ctx, userID, scanID)
+	if err != nil {
+		return db.Scan{}, nil, err
+	}
+	results, err := s.store.ListScanResults(ctx, scan.ID)
+	if err != nil {
+		return db.Scan{}, nil, err
+	}
+	return scan, results, nil
+}
+
+// LinkSources maps the links of a scan to the pages they were found on.
+func (s *Service) LinkSources(ctx context.Context, scanID int32) (map[string][]string, error) {
+	occurrences, err := s.store.ListLinkOccurrences(ctx, scanID)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string][]string)
+	for _, o := range occurrences {
+		sources[o.LinkUrl] = append(sources[o.LinkUrl], o.PageUrl)
+	}
+	return sources, nil
+}
+
+// WriteExport writes the scan and its results to w in the given format.
+// sources maps links to the pages they were found on, as LinkSources does;
+// links without any are reported at the page they were recorded with.
+func WriteExport(w io.Writer, format ExportFormat, scan db.Scan, results []db.Result, sources map[string][]string) error {
+	switch format {
+	case ExportCSV:
+		return writeCSV(w, results, sources)
+	case ExportJSON:
+		return writeJSON(w, scan, results)
+	case ExportJUnit:
+		return writeJUnit(w, scan, results, sources)
+	case ExportSARIF:
+		return writeSARIF(w, scan, results, sources)
+	case ExportMarkdown:
+		return writeMarkdown(w, scan, results, sources)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// foundOn returns the pages a result's link was found on, in order. Links
+// checked from a list, and the start URL, fall back to the result's page.
+func foundOn(r db.Result, sources map[string][]string) []string {
+	pages := sources[r.LinkUrl]
+	if len(pages) == 0 {
+		return []string{r.PageUrl}
+	}
+	return slices.Sorted(slices.Values(pages))
+}
+
+// writeCSV writes a row for every page a link was found on.
+func writeCSV(w io.Writer, results []db.Result, sources map[string][]string) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"page_url", "link_url", "category", "broken", "status_code", "content_type", "error", "warning",
+		"remote_ip", "cached", "total_ms", "checked_at",
+	})
+	for _, r := range results {
+		for _, page := range foundOn(r, sources) {
+			cw.Write([]string{
+				page,
+				r.LinkUrl,
+				r.Category,
+				strconv.FormatBool(IsBrokenResult(r)),
+				strconv.Itoa(int(r.StatusCode)),
+				r.ContentType,
+				r.ErrorDetail,
+				r.Warning,
+				r.RemoteIp,
+				strconv.FormatBool(r.Cached),
+				strconv.Itoa(int(r.TotalMs)),
+				r.CheckedAt.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON uses the same shapes as the JSON API.
+func writeJSON(w io.Writer, scan db.Scan, results []db.Result) error {
+	items := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		items = append(items, map[string]any(resultJSON(r)))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"scan":    map[string]any(scanJSON(scan)),
+		"summary": exportCounts(results),
+		"results": items,
+	})
+}
+
+func exportCounts(results []db.Result) map[string]int {
+	counts := map[string]int{"total": len(results), "broken": 0}
+	for _, r := range results {
+		counts[r.Category]++
+		if IsBrokenResult(r) {
+			counts["broken"]++
+		}
+	}
+	return counts
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// writeJUnit reports one test case per link, failing for broken links, so
+// CI systems can show dead links as failed tests. A case is classed under the
+// first page its link was found on.
+func writeJUnit(w io.Writer, scan db.Scan, results []db.Result, sources map[string][]string) error {
+	suite := junitSuite{
+		Name:      scan.StartUrl,
+		Tests:     len(results),
+		Time:      seconds(0),
+		Timestamp: scan.StartedAt.UTC().Format("2006-01-02T15:04:05"),
+	}
+	if scan.FinishedAt.Valid {
+		suite.Time = seconds(scan.FinishedAt.Time.Sub(scan.StartedAt).Milliseconds())
+	}
+
+	for _, r := range results {
+		tc := junitCase{
+			Name:      r.LinkUrl,
+			ClassName: foundOn(r, sources)[0],
+			Time:      seconds(int64(r.TotalMs)),
+			SystemOut: r.Warning,
+		}
+		switch category := Category(r.Category); {
+		case category == CategorySkipped:
+			tc.Skipped = &junitSkipped{Message: r.ErrorDetail}
+			suite.Skipped++
+		case IsBrokenResult(r):
+			tc.Failure = &junitFailure{Message: resultStatus(r), Type: r.Category, Text: r.ErrorDetail}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitSuites{
+		Name:     "deadlink",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 3, 64)
+}
+
+// writeSARIF reports broken links as errors and warnings (slow responses,
+// expiring certificates) as warnings, located at every page that links to
+// them.
+func writeSARIF(w io.Writer, scan db.Scan, results []db.Result, sources map[string][]string) error {
+	const warningRule = "link_warning"
+
+	ruleIndex := make(map[string]int)
+	rules := []map[string]any{}
+	rule := func(id, description string) {
+		if _, ok := ruleIndex[id]; ok {
+			return
+		}
+		ruleIndex[id] = len(rules)
+		rules = append(rules, map[string]any{
+			"id":               id,
+			"shortDescription": map[string]string{"text": description},
+		})
+	}
+
+	findings := []map[string]any{}
+	add := func(r db.Result, ruleID, level, text string) {
+		locations := []map[string]any{}
+		for _, page := range foundOn(r, sources) {
+			locations = append(locations, map[string]any{
+				"physicalLocation": map[string]any{
+					"artifactLocation": map[string]string{"uri": page},
+				},
+			})
+		}
+		findings = append(findings, map[string]any{
+			"ruleId":              ruleID,
+			"ruleIndex":           ruleIndex[ruleID],
+			"level":               level,
+			"message":             map[string]string{"text": text},
+			"locations":           locations,
+			"partialFingerprints": map[string]string{"linkUrl": r.LinkUrl},
+		})
+	}
+
+	for _, r := range results {
+		if IsBrokenResult(r) {
+			rule(r.Category, "Broken link: "+strings.ReplaceAll(r.Category, "_", " "))
+			text := fmt.Sprintf("Broken link %s (%s)", r.LinkUrl, resultStatus(r))
+			if r.ErrorDetail != "" {
+				text += ": " + r.ErrorDetail
+			}
+			add(r, r.Category, "error", text)
+		}
+		if r.Warning != "" {
+			rule(warningRule, "Link works but needs attention")
+			add(r, warningRule, "warning", fmt.Sprintf("%s: %s", r.LinkUrl, r.Warning))
+		}
+	}
+
+	doc := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool": map[string]any{
+				"driver": map[string]any{
+					"name":  "deadlink",
+					"rules": rules,
+				},
+			},
+			"automationDetails": map[string]string{"id": "deadlink/" + strconv.Itoa(int(scan.ID))},
+			"originalUriBaseIds": map[string]any{
+				"SITE": map[string]string{"uri": scan.StartUrl},
+			},
+			"results": findings,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func writeMarkdown(w io.Writer, scan db.Scan, results []db.Result, sources map[string][]string) error {
+	counts := exportCounts(results)
+	escape := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Link report for %s\n\n", scan.StartUrl)
+	if scan.ID != 0 {
+		fmt.Fprintf(&b, "Scan #%d started %s. ", scan.ID, scan.StartedAt.UTC().Format("2006-01-02 15:04 MST"))
+	} else {
+		fmt.Fprintf(&b, "Scan started %s. ", scan.StartedAt.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	fmt.Fprintf(&b, "%d links checked, %d broken.\n\n", counts["total"], counts["broken"])
+
+	b.WriteString("| Category | Links |\n|---|---:|\n")
+	for _, c := range Categories {
+		if n := counts[string(c)]; n > 0 {
+			fmt.Fprintf(&b, "| %s | %d |\n", c, n)
+		}
+	}
+
+	var broken, warned []db.Result
+	for _, r := range results {
+		if IsBrokenResult(r) {
+			broken = append(broken, r)
+		} else if r.Warning != "" {
+			warned = append(warned, r)
+		}
+	}
+
+	if len(broken) > 0 {
+		b.WriteString("\n## Broken links\n\n| Link | Status | Found on | Error |\n|---|---|---|---|\n")
+		for _, r := range broken {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", escape(r.LinkUrl), resultStatus(r), escape(strings.Join(foundOn(r, sources), " ")), escape(r.ErrorDetail))
+		}
+	}
+	if len(warned) > 0 {
+		b.WriteString("\n## Warnings\n\n| Link | Warning |\n|---|---|\n")
+		for _, r := range warned {
+			fmt.Fprintf(&b, "| %s | %s |\n", escape(r.LinkUrl), escape(r.Warning))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func resultStatus(r db.Result) string {
+	if r.StatusCode > 0 {
+		return strconv.Itoa(int(r.StatusCode)) + " " + r.Category
+	}
+	return r.Category
+}
@@ -1,9 +1,19 @@
 package scanner
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
 	scannerui "go-deadlink-scanner/internal/templates/scanner"
+	"go-deadlink-scanner/internal/templates/shared"
 	"go-deadlink-scanner/internal/ui"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/a-h/templ"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -36,18 +46,676 @@ func (h *Handler) StartScan(c *fiber.Ctx) error {
 		})
 	}
 
-	results, err := h.service.Scan(pageURL, userId)
+	opts, err := ParseScanOptions(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := h.service.Scan(pageURL, userId, opts)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Error scanning: " + err.Error())
 	}
 
-	var rows []scannerui.ResultRow
+	return ui.RenderComponent(c, scannerui.ResultsTable(toRows(report.Results), pageURL, "", report.ScanID))
+}
+
+// CheckList checks the URLs posted as a "urls" field, an uploaded "file" or
+// the request body, one per line or as CSV, without crawling.
+func (h *Handler) CheckList(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	name, list, err := readList(c, "urls")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	urls, err := ParseURLList(list)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	opts, err := ParseScanOptions(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := h.service.CheckList(name, urls, userId, opts)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error checking list: " + err.Error())
+	}
+
+	return ui.RenderComponent(c, scannerui.ResultsTable(toRows(report.Results), report.StartURL, "", report.ScanID))
+}
+
+// VerifyRedirects checks a redirect map of old and expected new URLs posted
+// as CSV in a "map" field, an uploaded "file" or the request body.
+func (h *Handler) VerifyRedirects(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	name, list, err := readList(c, "map")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	redirects, err := ParseRedirectMap(list)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	maxHops := DefaultMaxHops
+	if raw := c.FormValue("max_hops"); raw != "" {
+		if maxHops, err = strconv.Atoi(raw); err != nil || maxHops < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "max_hops must be a positive number",
+			})
+		}
+	}
+
+	opts, err := ParseScanOptions(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := h.service.VerifyRedirects(name, redirects, maxHops, userId, opts)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error checking redirects: " + err.Error())
+	}
+
+	return ui.RenderComponent(c, scannerui.ResultsTable(toRows(report.Results), report.StartURL, "", report.ScanID))
+}
+
+// readList returns a list posted as an uploaded "file", the given form field
+// or the request body, and the name it is recorded under: the "name" field,
+// else the uploaded file's name.
+func readList(c *fiber.Ctx, field string) (string, io.Reader, error) {
+	name := c.FormValue("name")
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return "", nil, err
+		}
+		if name == "" {
+			name = file.Filename
+		}
+		return name, bytes.NewReader(data), nil
+	}
+	if list := c.FormValue(field); list != "" {
+		return name, strings.NewReader(list), nil
+	}
+	return name, bytes.NewReader(c.Body()), nil
+}
+
+func (h *Handler) ListResults(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	var category Category
+	if raw := c.Query("category"); raw != "" {
+		category, ok = ParseCategory(raw)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unknown category: " + raw,
+			})
+		}
+	}
+
+	pageURL := c.Query("page_url")
+	limit := c.QueryInt("limit", 500)
+	offset := c.QueryInt("offset", 0)
+
+	results, err := h.service.ListResults(c.Context(), userId, pageURL, category, int32(limit), int32(offset))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load results",
+		})
+	}
+
+	if ui.IsHX(c) {
+		return ui.RenderComponent(c, scannerui.ResultsTable(toRows(results), pageURL, string(category), 0))
+	}
+
+	items := make([]fiber.Map, 0, len(results))
 	for _, r := range results {
-		rows = append(rows, scannerui.ResultRow{
-			Link:   r.LinkUrl,
-			Status: r.Status,
+		items = append(items, resultJSON(r))
+	}
+	return c.JSON(fiber.Map{"results": items})
+}
+
+func (h *Handler) ListScans(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	scans, err := h.service.ListScans(c.Context(), userId, int32(c.QueryInt("limit", 100)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load scans",
+		})
+	}
+
+	items := make([]fiber.Map, 0, len(scans))
+	for _, s := range scans {
+		items = append(items, scanJSON(s))
+	}
+	return c.JSON(fiber.Map{"scans": items})
+}
+
+// ComparePage diffs two scans. Without query parameters it compares the
+// latest scan with the previous scan of the same site.
+func (h *Handler) ComparePage(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	scans, err := h.service.ListScans(c.Context(), userId, 100)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading scans: " + err.Error())
+	}
+	if len(scans) < 2 {
+		return ui.RenderComponent(c, scannerui.ComparePage(nil, 0, 0, nil))
+	}
+
+	targetID := int32(c.QueryInt("target", int(scans[0].ID)))
+	baseID := int32(c.QueryInt("base", 0))
+	if baseID == 0 {
+		baseID = scans[1].ID
+		for _, s := range scans {
+			if s.ID == targetID {
+				if prev, found, err := h.service.PreviousScan(c.Context(), userId, s); err == nil && found {
+					baseID = prev.ID
+				}
+				break
+			}
+		}
+	}
+
+	choices := make([]scannerui.ScanChoice, 0, len(scans))
+	for _, s := range scans {
+		choices = append(choices, scannerui.ScanChoice{
+			ID:    s.ID,
+			Label: fmt.Sprintf("#%d %s (%s)", s.ID, s.StartUrl, s.StartedAt.Format("2006-01-02 15:04")),
 		})
 	}
 
-	return ui.RenderComponent(c, scannerui.ResultsTable(rows, pageURL))
+	var diffView templ.Component
+	diff, err := h.service.CompareScans(c.Context(), userId, baseID, targetID)
+	if err != nil {
+		diffView = shared.ErrorList([]string{err.Error()})
+	} else {
+		diffView = diffTable(diff)
+	}
+
+	return ui.RenderComponent(c, scannerui.ComparePage(choices, baseID, targetID, diffView))
+}
+
+func (h *Handler) CompareScans(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	baseID, targetID := c.QueryInt("base"), c.QueryInt("target")
+	if baseID <= 0 || targetID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "base and target scan ids are required",
+		})
+	}
+
+	diff, err := h.service.CompareScans(c.Context(), userId, int32(baseID), int32(targetID))
+	if err != nil {
+		if ui.IsHX(c) {
+			return ui.RenderComponent(c, shared.ErrorList([]string{err.Error()}))
+		}
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrScanNotFound):
+			status = fiber.StatusNotFound
+		case errors.Is(err, ErrDifferentSite):
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if ui.IsHX(c) {
+		return ui.RenderComponent(c, diffTable(diff))
+	}
+
+	summary := fiber.Map{"unchanged": diff.Unchanged}
+	for _, status := range DiffStatuses {
+		summary[string(status)] = diff.Count(status)
+	}
+
+	links := make([]fiber.Map, 0, len(diff.Entries))
+	for _, e := range diff.Entries {
+		link := fiber.Map{"url": e.URL, "status": e.Status, "before": nil, "after": nil}
+		if e.Before != nil {
+			link["before"] = resultJSON(*e.Before)
+		}
+		if e.After != nil {
+			link["after"] = resultJSON(*e.After)
+		}
+		links = append(links, link)
+	}
+
+	return c.JSON(fiber.Map{
+		"base":    scanJSON(diff.Base),
+		"target":  scanJSON(diff.Target),
+		"summary": summary,
+		"links":   links,
+	})
+}
+
+// ExportScan downloads a scan's results as CSV, JSON, JUnit XML, SARIF or
+// Markdown.
+func (h *Handler) ExportScan(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid scan id",
+		})
+	}
+
+	format, ok := ParseExportFormat(c.Query("format", string(ExportJSON)))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "unknown export format: " + c.Query("format"),
+		})
+	}
+
+	scan, results, err := h.service.ScanResults(c.Context(), userId, int32(id))
+	if err != nil {
+		if errors.Is(err, ErrScanNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load results",
+		})
+	}
+
+	sources, err := h.service.LinkSources(c.Context(), scan.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load results",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, format, scan, results, sources); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Attachment sets a Content-Type from the extension, so override it after.
+	c.Attachment(fmt.Sprintf("deadlinks-scan-%d.%s", scan.ID, format.Extension()))
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	return c.Send(buf.Bytes())
+}
+
+// RecheckBroken checks the broken links of a finished scan again and
+// reports which of them are fixed.
+func (h *Handler) RecheckBroken(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid scan id",
+		})
+	}
+
+	recheck, err := h.service.RecheckBroken(c.Context(), userId, int32(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrScanNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, ErrScanUnfinished), errors.Is(err, ErrRecheckRedirects):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString("Error rechecking: " + err.Error())
+	}
+
+	if ui.IsHX(c) {
+		return ui.RenderComponent(c, scannerui.ResultsTable(toRows(recheck.Results), recheck.StartURL, "", recheck.ScanID))
+	}
+
+	fixed := make([]fiber.Map, 0, len(recheck.Fixed))
+	for _, r := range recheck.Fixed {
+		fixed = append(fixed, resultJSON(r))
+	}
+	stillBroken := make([]fiber.Map, 0, len(recheck.StillBroken))
+	for _, r := range recheck.StillBroken {
+		stillBroken = append(stillBroken, resultJSON(r))
+	}
+	return c.JSON(fiber.Map{
+		"scan_id":      recheck.ScanID,
+		"fixed":        fixed,
+		"still_broken": stillBroken,
+	})
+}
+
+// DownloadBaseline returns a baseline file accepting every broken link of a
+// scan, to regenerate a checked-in baseline.
+func (h *Handler) DownloadBaseline(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid scan id",
+		})
+	}
+
+	scan, results, err := h.service.ScanResults(c.Context(), userId, int32(id))
+	if err != nil {
+		if errors.Is(err, ErrScanNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load results",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := NewBaseline(scan.StartUrl, results, time.Now()).Write(&buf); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Attachment(".deadlink-baseline.json")
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(buf.Bytes())
+}
+
+// CheckBaseline reports the broken links of a scan that are not in the
+// posted baseline, either a "baseline" file upload or the request body.
+func (h *Handler) CheckBaseline(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid scan id",
+		})
+	}
+
+	var raw io.Reader = bytes.NewReader(c.Body())
+	if file, err := c.FormFile("baseline"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "failed to read baseline upload",
+			})
+		}
+		defer f.Close()
+		raw = f
+	}
+
+	baseline, err := ReadBaseline(raw)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	scan, results, err := h.service.ScanResults(c.Context(), userId, int32(id))
+	if err != nil {
+		if errors.Is(err, ErrScanNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load results",
+		})
+	}
+
+	check := baseline.Check(results)
+	newBroken := make([]fiber.Map, 0, len(check.New))
+	for _, r := range check.New {
+		newBroken = append(newBroken, resultJSON(r))
+	}
+	fixed := make([]string, 0, len(check.Fixed))
+	for _, l := range check.Fixed {
+		fixed = append(fixed, l.URL)
+	}
+
+	return c.JSON(fiber.Map{
+		"scan":       scanJSON(scan),
+		"passed":     len(check.New) == 0,
+		"new_broken": newBroken,
+		"accepted":   len(check.Accepted),
+		"fixed":      fixed,
+	})
+}
+
+func diffTable(diff *ScanDiff) templ.Component {
+	counts := make([]scannerui.DiffCount, 0, len(DiffStatuses))
+	for _, status := range DiffStatuses {
+		counts = append(counts, scannerui.DiffCount{Status: string(status), Count: diff.Count(status)})
+	}
+
+	rows := make([]scannerui.DiffRow, 0, len(diff.Entries))
+	for _, e := range diff.Entries {
+		row := scannerui.DiffRow{Link: e.URL, Status: string(e.Status)}
+		if e.Before != nil {
+			before := toRow(*e.Before)
+			row.Before = &before
+		}
+		if e.After != nil {
+			after := toRow(*e.After)
+			row.After = &after
+		}
+		rows = append(rows, row)
+	}
+
+	return scannerui.DiffTable(counts, diff.Unchanged, rows, diff.Target.ID)
+}
+
+func scanJSON(s db.Scan) fiber.Map {
+	scan := fiber.Map{
+		"id":          s.ID,
+		"start_url":   s.StartUrl,
+		"started_at":  s.StartedAt,
+		"finished_at": nil,
+	}
+	if s.FinishedAt.Valid {
+		scan["finished_at"] = s.FinishedAt.Time
+	}
+	return scan
+}
+
+func resultJSON(r db.Result) fiber.Map {
+	result := fiber.Map{
+		"page_url":     r.PageUrl,
+		"link_url":     r.LinkUrl,
+		"category":     r.Category,
+		"status_code":  r.StatusCode,
+		"content_type": r.ContentType,
+		"error":        r.ErrorDetail,
+		"warning":      r.Warning,
+		"remote_ip":    r.RemoteIp,
+		"cached":       r.Cached,
+		"severity":     r.Severity,
+		"checked_at":   r.CheckedAt,
+		"fixed_at":     nil,
+		"timing": fiber.Map{
+			"dns_ms":     r.DnsMs,
+			"connect_ms": r.ConnectMs,
+			"tls_ms":     r.TlsMs,
+			"ttfb_ms":    r.TtfbMs,
+			"total_ms":   r.TotalMs,
+		},
+	}
+	if r.FixedAt.Valid {
+		result["fixed_at"] = r.FixedAt.Time
+	}
+	return result
+}
+
+// ParseScanOptions reads the optional scan settings posted by the scan and
+// schedule forms.
+func ParseScanOptions(c *fiber.Ctx) (ScanOptions, error) {
+	headers, err := ParseHeaderLines(c.FormValue("auth_headers"))
+	if err != nil {
+		return ScanOptions{}, err
+	}
+
+	cookies, err := ParseCookieLines(c.FormValue("auth_cookies"))
+	if err != nil {
+		return ScanOptions{}, err
+	}
+
+	creds := &Credentials{
+		BasicUser:   c.FormValue("auth_user"),
+		BasicPass:   c.FormValue("auth_pass"),
+		BearerToken: c.FormValue("auth_bearer"),
+		Headers:     headers,
+		Cookies:     cookies,
+	}
+
+	if loginURL := c.FormValue("login_url"); loginURL != "" {
+		fields, err := ParseFieldLines(c.FormValue("login_fields"))
+		if err != nil {
+			return ScanOptions{}, err
+		}
+		creds.Login = &LoginStep{
+			URL:         loginURL,
+			Fields:      fields,
+			SuccessText: c.FormValue("login_success_text"),
+		}
+	}
+	if creds.IsEmpty() {
+		creds = nil
+	}
+
+	overrides, err := ParseHostOverrides(c.FormValue("host_overrides"))
+	if err != nil {
+		return ScanOptions{}, err
+	}
+
+	transport := &TransportOptions{
+		ProxyURL:      strings.TrimSpace(c.FormValue("proxy_url")),
+		NoProxy:       strings.FieldsFunc(c.FormValue("no_proxy"), func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }),
+		CACertsPEM:    strings.TrimSpace(c.FormValue("ca_pem")),
+		ClientCertPEM: strings.TrimSpace(c.FormValue("client_cert_pem")),
+		ClientKeyPEM:  strings.TrimSpace(c.FormValue("client_key_pem")),
+		HostOverrides: overrides,
+		DNSServer:     strings.TrimSpace(c.FormValue("dns_server")),
+	}
+	if transport.IsEmpty() {
+		transport = nil
+	}
+
+	return ScanOptions{
+		Credentials: creds,
+		Transport:   transport,
+		BypassCache: c.FormValue("bypass_cache") == "on",
+	}, nil
+}
+
+func toRows(results []db.Result) []scannerui.ResultRow {
+	var rows []scannerui.ResultRow
+	for _, r := range results {
+		rows = append(rows, toRow(r))
+	}
+	return rows
+}
+
+func toRow(r db.Result) scannerui.ResultRow {
+	return scannerui.ResultRow{
+		Link:       r.LinkUrl,
+		Category:   r.Category,
+		StatusCode: int(r.StatusCode),
+		Error:      r.ErrorDetail,
+		Warning:    r.Warning,
+		RemoteIP:   r.RemoteIp,
+		Cached:     r.Cached,
+		Warned:     Severity(r.Severity) == SeverityWarning,
+		Duration:   formatDuration(r.TotalMs),
+		TimingDetail: fmt.Sprintf("DNS %d ms, connect %d ms, TLS %d ms, first byte %d ms",
+			r.DnsMs, r.ConnectMs, r.TlsMs, r.TtfbMs),
+	}
+}
+
+func formatDuration(ms int32) string {
+	if ms <= 0 {
+		return ""
+	}
+	if ms < 1000 {
+		return fmt.Sprintf("%d ms", ms)
+	}
+	return fmt.Sprintf("%.2f s", float64(ms)/1000)
 }
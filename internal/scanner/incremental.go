@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/pkg/deadlink"
+	"log"
+)
+
+// pageStore keeps the validators and links of the pages a user's scans
+// crawled, so the next scan can send conditional requests.
+type pageStore struct {
+	queries *db.Queries
+	userID  int32
+}
+
+// LoadPage returns what the last scan of this user stored for the page, or
+// nil when the page has not been seen.
+func (p *pageStore) LoadPage(ctx context.Context, pageURL string) *deadlink.Page {
+	page, err := p.queries.GetPageCache(ctx, db.GetPageCacheParams{UserID: p.userID, Url: pageURL})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to load page cache for %s: %v", pageURL, err)
+		}
+		return nil
+	}
+	return &deadlink.Page{ETag: page.Etag, LastModified: page.LastModified, Links: page.Links}
+}
+
+// SavePage stores the page's validators and links for the next scan.
+func (p *pageStore) SavePage(ctx context.Context, pageURL string, page deadlink.Page) {
+	err := p.queries.UpsertPageCache(ctx, db.UpsertPageCacheParams{
+		UserID:       p.userID,
+		Url:          pageURL,
+		Etag:         page.ETag,
+		LastModified: page.LastModified,
+		Links:        page.Links,
+	})
+	if err != nil {
+		log.Printf("Failed to save page cache for %s: %v", pageURL, err)
+	}
+}
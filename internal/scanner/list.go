@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// maxListURLs bounds how many links one URL list may check.
+const maxListURLs = 10000
+
+var (
+	ErrEmptyList   = errors.New("no http(s) URLs in list")
+	ErrListTooLong = fmt.Errorf("list has more than %d URLs", maxListURLs)
+)
+
+// ListLabel is the start URL recorded for a URL list check, so that checks
+// of the same list are compared with each other.
+func ListLabel(name string) string {
+	if name = strings.TrimSpace(name); name == "" {
+		name = "urls"
+	}
+	return "list:" + name
+}
+
+// ParseURLList reads one URL per line, or CSV rows where the first column
+// holding an http(s) URL is used. The input is read as CSV when it starts
+// with a header row or every line has the same number of fields, more than
+// one; otherwise a line that is a single URL is taken as is, commas
+// included. Header rows, blank lines and lines starting with # are skipped,
+// and duplicates dropped.
+func ParseURLList(r io.Reader) ([]string, error) {
+	var lines []string
+	input := bufio.NewScanner(r)
+	input.Buffer(make([]byte, 64*1024), 1024*1024)
+	for input.Scan() {
+		line := strings.TrimSpace(input.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := input.Err(); err != nil {
+		return nil, err
+	}
+
+	isCSV := isCSVList(lines)
+	var urls []string
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		link := line
+		if isCSV || !isSingleURL(line) {
+			link = firstURLField(line)
+		}
+		if link == "" || seen[link] {
+			continue
+		}
+		seen[link] = true
+		urls = append(urls, link)
+		if len(urls) > maxListURLs {
+			return nil, ErrListTooLong
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, ErrEmptyList
+	}
+	return urls, nil
+}
+
+// isCSVList reports whether lines are CSV rows: the first is a header row
+// with several fields, or there are several lines that all have the same
+// number of fields, more than one. A lone line is only CSV with quotes,
+// spaces or a second URL, see isSingleURL.
+func isCSVList(lines []string) bool {
+	if len(lines) == 0 {
+		return false
+	}
+	if first := csvFields(lines[0]); len(first) > 1 && !isHTTPURL(lines[0]) {
+		return true
+	}
+	if len(lines) == 1 {
+		return false
+	}
+	count := 0
+	for _, line := range lines {
+		n := len(csvFields(line))
+		if n < 2 || (count > 0 && n != count) {
+			return false
+		}
+		count = n
+	}
+	return true
+}
+
+func csvFields(line string) []string {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	fields, err := reader.Read()
+	if err != nil {
+		return nil
+	}
+	return fields
+}
+
+func firstURLField(line string) string {
+	for _, f := range csvFields(line) {
+		if f = strings.TrimSpace(f); isHTTPURL(f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// isSingleURL reports whether line is one absolute http(s) URL rather than
+// a CSV row: CSV fields are usually separated by spaces or quoted, or hold
+// another URL.
+func isSingleURL(line string) bool {
+	lower := strings.ToLower(line)
+	if !isHTTPURL(line) || strings.ContainsAny(line, " \t\"") ||
+		strings.Contains(lower, ",http://") || strings.Contains(lower, ",https://") {
+		return false
+	}
+	u, err := url.Parse(line)
+	return err == nil && u.Host != "" && !strings.Contains(u.Host, ",")
+}
+
+func isHTTPURL(s string) bool {
+	lower := strings.ToLower(s)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// CheckList checks every URL once, without following links, and records the
+// outcome as a scan of ListLabel(name). Credentials are sent to the host of
+// the first URL only.
+func (s *Service) CheckList(name string, urls []string, userID int32, opts ScanOptions) (*Report, error) {
+	label := ListLabel(name)
+	report, err := s.checkList(label, urls, userID, opts)
+	s.notify(userID, label, report, err)
+	return report, err
+}
+
+func (s *Service) checkList(label string, urls []string, userID int32, opts ScanOptions) (*Report, error) {
+	if len(urls) == 0 {
+		return nil, ErrEmptyList
+	}
+	if len(urls) > maxListURLs {
+		return nil, ErrListTooLong
+	}
+
+	baseURL, err := url.Parse(urls[0])
+	if err != nil {
+		baseURL = &url.URL{}
+	}
+	session, err := s.newSession(userID, baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scanID, err := s.createScan(context.Background(), userID, label, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.checkAll(urls, session)
+	log.Printf("List check %s completed. Checked %d links", label, session.resultCount())
+
+	dbResults := s.saveResults(userID, label, scanID, session)
+
+	s.finishScan(scanID)
+
+	return &Report{ScanID: scanID.Int32, StartURL: label, Results: dbResults}, nil
+}
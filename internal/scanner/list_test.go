@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseURLList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "urls with commas",
+			input: "https://a.com/x?ids=1,2\nhttps://a.com/y\nhttps://a.com/z;a,b\n",
+			want:  []string{"https://a.com/x?ids=1,2", "https://a.com/y", "https://a.com/z;a,b"},
+		},
+		{
+			name:  "headerless csv",
+			input: "https://a.com/x,404\nhttps://a.com/y,200\n",
+			want:  []string{"https://a.com/x", "https://a.com/y"},
+		},
+		{
+			name:  "headerless csv with dates",
+			input: "https://a.com/x,2024-01-01\nhttps://a.com/y,2024-01-02\n",
+			want:  []string{"https://a.com/x", "https://a.com/y"},
+		},
+		{
+			name:  "csv with header",
+			input: "url,status\nhttps://a.com/x?ids=1,404\n",
+			want:  []string{"https://a.com/x?ids=1"},
+		},
+		{
+			name:  "csv with quotes",
+			input: "# export\n\"page\",\"https://a.com/x\"\n",
+			want:  []string{"https://a.com/x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURLList(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseURLList: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseURLList = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
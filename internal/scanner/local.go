@@ -0,0 +1,328 @@
+package scanner
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"go-deadlink-scanner/pkg/deadlink"
+	"io/fs"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// LocalOptions configure an offline scan of a built site.
+type LocalOptions struct {
+	// BaseURL is the address the site is published at. Absolute links to
+	// its host are resolved to files like relative ones.
+	BaseURL string
+	// CheckExternal checks links to other hosts over HTTP. Otherwise they
+	// are reported as skipped.
+	CheckExternal bool
+}
+
+// localSite is a directory of HTML or Markdown files as a static host or a
+// Git forge serves them.
+type localSite struct {
+	root   string
+	base   *url.URL
+	prefix string
+	// anchors holds the ids of each page, or its heading slugs for
+	// Markdown, keyed by its slash separated path below root.
+	anchors map[string]map[string]bool
+	// links maps every link to the pages it appears on.
+	links map[string][]string
+}
+
+// ScanDir checks the links of every HTML and Markdown file under root
+// without a server. Internal links must resolve to a file, and their
+// fragment to an id or heading in the target page.
+func (s *Service) ScanDir(root string, local LocalOptions, opts ScanOptions) (*Report, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	base := &url.URL{Path: "/"}
+	startURL := root
+	if local.BaseURL != "" {
+		base, err = url.Parse(local.BaseURL)
+		if err != nil || base.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %q", local.BaseURL)
+		}
+		startURL = local.BaseURL
+	}
+
+	sess, err := s.newSession(0, base, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	site := &localSite{
+		root:    root,
+		base:    base,
+		prefix:  strings.TrimSuffix(base.Path, "/"),
+		anchors: make(map[string]map[string]bool),
+		links:   make(map[string][]string),
+	}
+	if err := site.load(); err != nil {
+		return nil, err
+	}
+
+	var external []string
+	for link := range site.links {
+		u, err := url.Parse(link)
+		if err != nil {
+			sess.results[link] = &ScanResult{URL: link, Category: CategoryInvalidURL, Error: err.Error()}
+			continue
+		}
+
+		result := &ScanResult{URL: link, Category: CategorySkipped}
+		switch reason := sess.rules.excluded(link); {
+		case u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https":
+			result.Error = fmt.Sprintf("unsupported scheme %q", u.Scheme)
+		case reason != "":
+			result.Error = reason
+		case site.internal(u):
+			result = site.check(link, u)
+			sess.applyRules(result)
+		case local.CheckExternal:
+			external = append(external, link)
+			continue
+		default:
+			result.Error = "external links are not checked offline"
+		}
+		sess.results[link] = result
+	}
+	s.checkAll(external, sess)
+
+	for link, result := range sess.results {
+		if IsBroken(result.Category) {
+			result.Error = deadlink.JoinWarnings(result.Error, linkedFrom(site.links[link]))
+		}
+	}
+	log.Printf("Offline scan of %s completed. Found %d links in %d pages", root, len(sess.results), len(site.anchors))
+
+	results := s.saveResults(0, startURL, sql.NullInt32{}, sess)
+	return &Report{StartURL: startURL, Results: results, Sources: site.links}, nil
+}
+
+// load parses every page under the root for its links and anchors. Hidden
+// directories and node_modules are not part of the site.
+func (site *localSite) load() error {
+	return filepath.WalkDir(site.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != site.root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isHTMLFile(p) && !isMarkdownFile(p) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(site.root, p)
+		if err != nil {
+			return err
+		}
+		file := "/" + filepath.ToSlash(rel)
+		pageURL := site.base.ResolveReference(&url.URL{Path: site.prefix + file})
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		if isMarkdownFile(p) {
+			page := parseMarkdown(string(data))
+			for _, href := range page.links {
+				if link := resolveLocal(href, pageURL); link != "" {
+					site.addLink(link, pageURL.String())
+				}
+			}
+			site.anchors[file] = page.anchors
+			return nil
+		}
+
+		doc, err := html.Parse(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", p, err)
+		}
+		anchors := make(map[string]bool)
+		site.traverse(doc, pageURL, anchors)
+		site.anchors[file] = anchors
+		return nil
+	})
+}
+
+func (site *localSite) traverse(n *html.Node, pageURL *url.URL, anchors map[string]bool) {
+	if n.Type == html.ElementNode {
+		for _, attr := range n.Attr {
+			switch {
+			case attr.Key == "id", n.Data == "a" && attr.Key == "name":
+				anchors[attr.Val] = true
+			case n.Data == "a" && attr.Key == "href":
+				if link := resolveLocal(attr.Val, pageURL); link != "" {
+					site.addLink(link, pageURL.String())
+				}
+			}
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		site.traverse(c, pageURL, anchors)
+	}
+}
+
+func (site *localSite) addLink(link, page string) {
+	pages := site.links[link]
+	if slices.Contains(pages, page) {
+		return
+	}
+	site.links[link] = append(pages, page)
+}
+
+// resolveLocal is resolveURL for pages on disk, keeping in-page anchors so
+// they are checked too.
+func resolveLocal(href string, pageURL *url.URL) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "mailto:") || strings.HasPrefix(href, "tel:") {
+		return ""
+	}
+
+	linkURL, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return pageURL.ResolveReference(linkURL).String()
+}
+
+func (site *localSite) internal(u *url.URL) bool {
+	return u.Host == "" || (site.base.Host != "" && strings.EqualFold(u.Host, site.base.Host))
+}
+
+// check resolves an internal link to a file and its fragment to an id or a
+// Markdown heading.
+func (site *localSite) check(link string, u *url.URL) *ScanResult {
+	result := &ScanResult{URL: link}
+
+	file, ok := site.file(u.Path)
+	if !ok {
+		result.Category = CategoryMissingFile
+		result.Error = "no file for " + u.Path
+		return result
+	}
+	result.Category = CategoryOK
+	result.ContentType = mime.TypeByExtension(path.Ext(file))
+
+	// Browsers scroll to the top for "#top" even without such an id.
+	if u.Fragment == "" || u.Fragment == "top" {
+		return result
+	}
+	if anchors, ok := site.anchors[file]; ok && !anchors[u.Fragment] {
+		result.Category = CategoryMissingAnchor
+		result.Error = fmt.Sprintf("no element with id %q in %s", u.Fragment, file)
+		if isMarkdownFile(file) {
+			result.Error = fmt.Sprintf("no heading or anchor %q in %s", u.Fragment, file)
+		}
+	}
+	return result
+}
+
+// file maps a URL path to a file the way static hosts do: directories serve
+// their index page and "/about" may be served by "about.html". Markdown
+// indexes and README.md files stand in for index.html, as on Git forges.
+func (site *localSite) file(urlPath string) (string, bool) {
+	if site.prefix != "" {
+		if urlPath != site.prefix && !strings.HasPrefix(urlPath, site.prefix+"/") {
+			return "", false
+		}
+		urlPath = strings.TrimPrefix(urlPath, site.prefix)
+	}
+
+	clean := path.Clean("/" + urlPath)
+	var candidates []string
+	if !strings.HasSuffix(urlPath, "/") {
+		candidates = append(candidates, clean)
+	}
+	for _, index := range []string{"index.html", "index.md", "README.md"} {
+		candidates = append(candidates, path.Join(clean, index))
+	}
+	if !strings.HasSuffix(urlPath, "/") {
+		candidates = append(candidates, clean+".html", clean+".md")
+	}
+
+	for _, c := range candidates {
+		info, err := os.Stat(filepath.Join(site.root, filepath.FromSlash(c)))
+		if err == nil && !info.IsDir() {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// checkAll checks links concurrently without following them.
+func (s *Service) checkAll(links []string, sess *scanSession) {
+	s.checkEach(links, sess, func(link string) *ScanResult {
+		return s.checkLink(link, sess)
+	})
+}
+
+// checkEach runs check for every link on the workers and records the
+// results in the session.
+func (s *Service) checkEach(links []string, sess *scanSession, check func(link string) *ScanResult) {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+
+	for i := 0; i < s.maxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				result := check(link)
+				sess.resultsMutex.Lock()
+				sess.results[link] = result
+				sess.resultsMutex.Unlock()
+			}
+		}()
+	}
+
+	for _, link := range links {
+		jobs <- link
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func isHTMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".html" || ext == ".htm"
+}
+
+func isMarkdownFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// linkedFrom names the pages a broken link appears on.
+func linkedFrom(pages []string) string {
+	const shown = 3
+	if len(pages) <= shown {
+		return "linked from " + strings.Join(pages, ", ")
+	}
+	return fmt.Sprintf("linked from %s and %d more pages", strings.Join(pages[:shown], ", "), len(pages)-shown)
+}
@@ -0,0 +1,244 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go-deadlink-scanner/pkg/deadlink"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// LoginStep describes a form login performed before crawling. The resulting
+// session cookies are kept in a per-scan cookie jar.
+type LoginStep struct {
+	URL    string            `json:"url"`
+	Fields map[string]string `json:"fields"`
+	// SuccessText must appear in the response to the login form. When empty
+	// the login succeeds if the response does not end on the login page.
+	SuccessText string `json:"success_text,omitempty"`
+}
+
+type loginState struct {
+	step     *LoginStep
+	loginURL *url.URL
+	mu       sync.Mutex
+	gen      int
+}
+
+// skipLink reports whether the link must not be requested because it would
+// end the login session.
+func (sess *scanSession) skipLink(u *url.URL) bool {
+	if sess.login == nil || u.Host != sess.baseURL.Host {
+		return false
+	}
+
+	path := strings.ToLower(u.Path)
+	for _, word := range []string{"logout", "log-out", "signout", "sign-out"} {
+		if strings.Contains(path, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sess *scanSession) loginGeneration() int {
+	if sess.login == nil {
+		return 0
+	}
+	sess.login.mu.Lock()
+	defer sess.login.mu.Unlock()
+	return sess.login.gen
+}
+
+func (sess *scanSession) isLoggedOut(requested *url.URL, resp *http.Response) bool {
+	if sess.login == nil || requested.Host != sess.baseURL.Host {
+		return false
+	}
+
+	loginURL := sess.login.loginURL
+	if requested.Host == loginURL.Host && requested.Path == loginURL.Path {
+		return false
+	}
+
+	final := resp.Request.URL
+	return final.Host == loginURL.Host && final.Path == loginURL.Path
+}
+
+// relogin performs the login unless another worker already did so since
+// generation seen was observed.
+func (sess *scanSession) relogin(seen int) error {
+	l := sess.login
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.gen != seen {
+		return nil
+	}
+	if err := sess.performLogin(); err != nil {
+		return err
+	}
+	l.gen++
+	return nil
+}
+
+func (sess *scanSession) performLogin() error {
+	step := sess.login.step
+	loginURL := sess.login.loginURL
+
+	req, err := http.NewRequest("GET", loginURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", deadlink.DefaultUserAgent)
+	resp, err := sess.client.Do(withCredentials(req, sess.creds, sess.baseURL))
+	if err != nil {
+		return fmt.Errorf("fetch login page: %w", err)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("read login page: %w", err)
+	}
+
+	action, form := loginForm(body, step.Fields)
+	for name, value := range step.Fields {
+		form.Set(name, value)
+	}
+	target := resp.Request.URL
+	if action != "" {
+		if actionURL, err := url.Parse(action); err == nil {
+			target = target.ResolveReference(actionURL)
+		}
+	}
+
+	req, err = http.NewRequest("POST", target.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", deadlink.DefaultUserAgent)
+	resp, err = sess.client.Do(withCredentials(req, sess.creds, sess.baseURL))
+	if err != nil {
+		return fmt.Errorf("submit login form: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("login form returned %d", resp.StatusCode)
+	}
+
+	if step.SuccessText != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		if err != nil {
+			return fmt.Errorf("read login response: %w", err)
+		}
+		if !strings.Contains(string(body), step.SuccessText) {
+			return fmt.Errorf("login response does not contain %q", step.SuccessText)
+		}
+		return nil
+	}
+
+	if final := resp.Request.URL; final.Host == loginURL.Host && final.Path == loginURL.Path {
+		return errors.New("still on the login page after submitting the form")
+	}
+	return nil
+}
+
+// loginForm finds the form that contains the configured fields (or the first
+// form on the page) and returns its action and prefilled inputs, so hidden
+// fields such as CSRF tokens are submitted too.
+func loginForm(page []byte, fields map[string]string) (string, url.Values) {
+	values := url.Values{}
+
+	doc, err := html.Parse(strings.NewReader(string(page)))
+	if err != nil {
+		return "", values
+	}
+
+	var forms []*html.Node
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "form" {
+			forms = append(forms, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(doc)
+	if len(forms) == 0 {
+		return "", values
+	}
+
+	chosen := forms[0]
+	for _, f := range forms {
+		if formHasField(f, fields) {
+			chosen = f
+			break
+		}
+	}
+
+	var fill func(*html.Node)
+	fill = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "input" {
+			name, value := attr(n, "name"), attr(n, "value")
+			if name != "" && attr(n, "type") != "submit" {
+				values.Set(name, value)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			fill(c)
+		}
+	}
+	fill(chosen)
+
+	return attr(chosen, "action"), values
+}
+
+func formHasField(n *html.Node, fields map[string]string) bool {
+	if n.Type == html.ElementNode && n.Data == "input" {
+		if _, ok := fields[attr(n, "name")]; ok {
+			return true
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if formHasField(c, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// ParseFieldLines parses one "name=value" pair per line. Values may contain
+// any character, including '=' and ';'.
+func ParseFieldLines(raw string) (map[string]string, error) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid form field %q, expected \"name=value\"", line)
+		}
+		fields[strings.TrimSpace(name)] = value
+	}
+	return fields, nil
+}
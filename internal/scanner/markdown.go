@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// markdownPage holds the links of a Markdown file and the anchors its
+// headings get when rendered.
+type markdownPage struct {
+	links   []string
+	anchors map[string]bool
+	slugs   map[string]int
+}
+
+var (
+	mdFence        = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdATXHeading   = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextLine   = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*$`)
+	mdBlockStart   = regexp.MustCompile(`^ {0,3}(?:[-*+][ \t]|\d+[.)][ \t]|>|\|)`)
+	mdReferenceDef = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:[ \t]*(<[^>]*>|\S+)`)
+	mdCodeSpan     = regexp.MustCompile("``[^`]*``|`[^`]*`")
+	mdAutolink     = regexp.MustCompile(`<((?:https?|ftp)://[^\s<>]+)>`)
+	mdBareURL      = regexp.MustCompile(`(?:^|[\s(])(https?://[^\s<>]+)`)
+	mdHTMLAttr     = regexp.MustCompile(`(?i)<[a-z][^>]*?\s(href|src|id|name)\s*=\s*"([^"]*)"`)
+	mdInlineLink   = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasis     = regexp.MustCompile(`(^|[^\p{L}\p{N}])[*_]+|[*_]+([^\p{L}\p{N}]|$)`)
+	mdHTMLTag      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// parseMarkdown extracts inline links, images, reference definitions,
+// autolinks and bare URLs, skipping code. It is a line based reading of
+// CommonMark that is good enough for documentation, not a full parser.
+func parseMarkdown(src string) *markdownPage {
+	page := &markdownPage{
+		anchors: make(map[string]bool),
+		slugs:   make(map[string]int),
+	}
+
+	var fence string
+	var paragraph []string
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if fence != "" {
+			if t := strings.TrimSpace(line); len(t) >= len(fence) && strings.Trim(t, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			paragraph = nil
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			paragraph = nil
+		case mdSetextLine.MatchString(line) && len(paragraph) > 0:
+			page.addHeading(strings.Join(paragraph, " "))
+			paragraph = nil
+		case mdATXHeading.MatchString(line):
+			text := mdATXHeading.FindStringSubmatch(line)[1]
+			page.addHeading(text)
+			page.scanLine(text)
+			paragraph = nil
+		case mdReferenceDef.MatchString(line):
+			page.addLink(strings.Trim(mdReferenceDef.FindStringSubmatch(line)[1], "<>"))
+			paragraph = nil
+		case mdBlockStart.MatchString(line):
+			page.scanLine(line)
+			paragraph = nil
+		default:
+			page.scanLine(line)
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	return page
+}
+
+func (p *markdownPage) scanLine(line string) {
+	line = mdCodeSpan.ReplaceAllString(line, "")
+
+	for i := 0; ; {
+		j := strings.Index(line[i:], "](")
+		if j < 0 {
+			break
+		}
+		start := i + j + 2
+		dest, n := linkDestination(line[start:])
+		p.addLink(dest)
+		i = start + n
+	}
+
+	for _, m := range mdAutolink.FindAllStringSubmatch(line, -1) {
+		p.addLink(m[1])
+	}
+	for _, m := range mdBareURL.FindAllStringSubmatch(line, -1) {
+		p.addLink(trimBareURL(m[1]))
+	}
+	for _, m := range mdHTMLAttr.FindAllStringSubmatch(line, -1) {
+		switch strings.ToLower(m[1]) {
+		case "href", "src":
+			p.addLink(m[2])
+		default:
+			p.anchors[m[2]] = true
+		}
+	}
+}
+
+func (p *markdownPage) addLink(link string) {
+	if link != "" {
+		p.links = append(p.links, link)
+	}
+}
+
+// addHeading records the anchor of a heading. Repeated headings get a
+// numbered suffix, as on GitHub.
+func (p *markdownPage) addHeading(text string) {
+	slug := githubSlug(text)
+	if n := p.slugs[slug]; n > 0 {
+		p.anchors[fmt.Sprintf("%s-%d", slug, n)] = true
+	} else {
+		p.anchors[slug] = true
+	}
+	p.slugs[slug]++
+}
+
+// linkDestination reads the destination of an inline link from just after
+// "](" and returns it with the number of bytes read.
+func linkDestination(s string) (string, int) {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	if i < len(s) && s[i] == '<' {
+		end := strings.IndexByte(s[i:], '>')
+		if end < 0 {
+			return "", i
+		}
+		return s[i+1 : i+end], i + end + 1
+	}
+
+	start, depth := i, 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s[start:i], i
+			}
+			depth--
+		case ' ', '\t':
+			return s[start:i], i
+		}
+	}
+	return "", len(s)
+}
+
+// trimBareURL drops trailing punctuation that ends the sentence rather than
+// the URL, and closing parentheses that were not opened in it.
+func trimBareURL(link string) string {
+	for {
+		trimmed := strings.TrimRight(link, ".,:;!?*_~'\"")
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, ")") > strings.Count(trimmed, "(") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == link {
+			return link
+		}
+		link = trimmed
+	}
+}
+
+// githubSlug turns heading text into its anchor the way GitHub does: the
+// rendered text in lower case, punctuation removed and spaces replaced by
+// hyphens.
+func githubSlug(heading string) string {
+	text := mdInlineLink.ReplaceAllString(heading, "$1")
+	text = strings.ReplaceAll(text, "`", "")
+	text = mdHTMLTag.ReplaceAllString(text, "")
+	text = mdEmphasis.ReplaceAllString(text, "$1$2")
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), unicode.IsMark(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
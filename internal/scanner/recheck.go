@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrScanUnfinished   = errors.New("scan has not finished")
+	ErrRecheckRedirects = errors.New("redirect map checks cannot be rechecked, verify the map again")
+)
+
+// Recheck is the outcome of rechecking the broken links of a scan. Results
+// holds every result of the scan after the update.
+type Recheck struct {
+	ScanID      int32
+	StartURL    string
+	Fixed       []db.Result
+	StillBroken []db.Result
+	Results     []db.Result
+}
+
+// RecheckBroken checks the broken links of a finished scan again without
+// crawling the site. The pages they were found on are fetched again too: a
+// link that none of them contains any more is recorded as removed. Links
+// that are no longer broken are marked fixed in place.
+func (s *Service) RecheckBroken(ctx context.Context, userID, scanID int32) (*Recheck, error) {
+	scan, err := s.userScan(ctx, userID, scanID)
+	if err != nil {
+		return nil, err
+	}
+	if !scan.FinishedAt.Valid {
+		return nil, fmt.Errorf("%w: %d", ErrScanUnfinished, scanID)
+	}
+	if strings.HasPrefix(scan.StartUrl, redirectLabelPrefix) {
+		return nil, ErrRecheckRedirects
+	}
+
+	opts, err := s.StoredOptions(scan)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Rules == nil {
+		opts.Rules = s.presetRules(ctx, userID, scan.StartUrl)
+	}
+	// The point is to confirm fixes, so results cached before them are
+	// of no use.
+	opts.BypassCache = true
+
+	results, err := s.store.ListScanResults(ctx, scan.ID)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := s.LinkSources(ctx, scan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var broken []*db.Result
+	var links []string
+	var pages []string
+	seenPage := make(map[string]bool)
+	for i := range results {
+		r := &results[i]
+		if !IsBrokenResult(*r) {
+			continue
+		}
+		broken = append(broken, r)
+		for _, page := range sources[r.LinkUrl] {
+			if !seenPage[page] {
+				seenPage[page] = true
+				pages = append(pages, page)
+			}
+		}
+	}
+
+	recheck := &Recheck{ScanID: scan.ID, StartURL: scan.StartUrl, Results: results}
+	if len(broken) == 0 {
+		return recheck, nil
+	}
+
+	baseURL, err := url.Parse(scan.StartUrl)
+	if err != nil || baseURL.Host == "" {
+		// URL lists have no start page; credentials go to the first host.
+		if baseURL, err = url.Parse(broken[0].LinkUrl); err != nil {
+			baseURL = &url.URL{}
+		}
+	}
+	sess, err := s.newSession(userID, baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	onPage := s.relinkPages(ctx, pages, sess)
+	removed := make(map[string]bool)
+	for _, r := range broken {
+		if linkRemoved(r.LinkUrl, sources[r.LinkUrl], onPage) {
+			removed[r.LinkUrl] = true
+		} else {
+			links = append(links, r.LinkUrl)
+		}
+	}
+	s.checkAll(links, sess)
+
+	byLink := make(map[string]*db.Result, len(results))
+	for i := range results {
+		byLink[results[i].LinkUrl] = &results[i]
+	}
+
+	// Source pages are results of the scan as well.
+	sess.resultsMutex.Lock()
+	defer sess.resultsMutex.Unlock()
+	for _, page := range pages {
+		if r, ok := byLink[page]; ok && !IsBrokenResult(*r) {
+			if result, ok := sess.results[page]; ok {
+				s.updateResult(ctx, r, result, sess.severity(page), r.FixedAt)
+			}
+		}
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	for _, r := range broken {
+		result := sess.results[r.LinkUrl]
+		if removed[r.LinkUrl] {
+			result = &ScanResult{
+				URL:      r.LinkUrl,
+				Category: CategoryRemoved,
+				Error:    "no longer " + linkedFrom(sources[r.LinkUrl]),
+			}
+		}
+		if result == nil {
+			continue
+		}
+
+		severity := sess.severity(r.LinkUrl)
+		if IsBroken(result.Category) && severity != SeverityWarning {
+			s.updateResult(ctx, r, result, severity, sql.NullTime{})
+			recheck.StillBroken = append(recheck.StillBroken, *r)
+		} else {
+			s.updateResult(ctx, r, result, severity, now)
+			recheck.Fixed = append(recheck.Fixed, *r)
+		}
+	}
+	log.Printf("Recheck of scan %d completed. %d of %d broken links fixed", scan.ID, len(recheck.Fixed), len(broken))
+
+	return recheck, nil
+}
+
+// relinkPages checks the pages again and returns the links of those that
+// still serve HTML. Pages that could not be read are left out, so their
+// links are not taken as removed.
+func (s *Service) relinkPages(ctx context.Context, pages []string, sess *scanSession) map[string]map[string]bool {
+	onPage := make(map[string]map[string]bool)
+	var mu sync.Mutex
+
+	s.checkEach(pages, sess, func(page string) *ScanResult {
+		result := s.checkLink(page, sess)
+		if result.StatusCode != 200 || !strings.Contains(result.ContentType, "text/html") || !sess.crawler.Crawlable(page) {
+			return result
+		}
+
+		links := make(map[string]bool)
+		for _, link := range sess.crawler.Links(ctx, page) {
+			links[link] = true
+		}
+		mu.Lock()
+		onPage[page] = links
+		mu.Unlock()
+		return result
+	})
+	return onPage
+}
+
+// linkRemoved reports whether every page a link was found on could be read
+// again and none of them contains it.
+func linkRemoved(link string, pages []string, onPage map[string]map[string]bool) bool {
+	if len(pages) == 0 {
+		return false
+	}
+	for _, page := range pages {
+		links, ok := onPage[page]
+		if !ok || links[link] {
+			return false
+		}
+	}
+	return true
+}
+
+// updateResult stores the outcome of a recheck in r and the store.
+func (s *Service) updateResult(ctx context.Context, r *db.Result, result *ScanResult, severity Severity, fixedAt sql.NullTime) {
+	r.Category = string(result.Category)
+	r.StatusCode = int32(result.StatusCode)
+	r.ContentType = result.ContentType
+	r.ErrorDetail = result.Error
+	r.Warning = result.Warning
+	r.DnsMs = int32(result.Timing.DNS.Milliseconds())
+	r.ConnectMs = int32(result.Timing.Connect.Milliseconds())
+	r.TlsMs = int32(result.Timing.TLS.Milliseconds())
+	r.TtfbMs = int32(result.Timing.TTFB.Milliseconds())
+	r.TotalMs = int32(result.Timing.Total.Milliseconds())
+	r.RemoteIp = result.RemoteIP
+	r.Cached = result.Cached
+	r.CheckedAt = time.Now()
+	r.FixedAt = fixedAt
+	r.Severity = string(severity)
+
+	err := s.store.UpdateResult(ctx, db.UpdateResultStatusParams{
+		ID:          r.ID,
+		Category:    r.Category,
+		StatusCode:  r.StatusCode,
+		ContentType: r.ContentType,
+		ErrorDetail: r.ErrorDetail,
+		Warning:     r.Warning,
+		DnsMs:       r.DnsMs,
+		ConnectMs:   r.ConnectMs,
+		TlsMs:       r.TlsMs,
+		TtfbMs:      r.TtfbMs,
+		TotalMs:     r.TotalMs,
+		RemoteIp:    r.RemoteIp,
+		Cached:      r.Cached,
+		FixedAt:     r.FixedAt,
+		Severity:    r.Severity,
+	})
+	if err != nil {
+		log.Printf("Failed to update result for %s: %v", r.LinkUrl, err)
+	}
+}
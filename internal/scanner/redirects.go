@@ -0,0 +1,247 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-deadlink-scanner/pkg/deadlink"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxHops is how many redirects an old URL may take to reach its new
+// URL before the chain is reported.
+const DefaultMaxHops = 3
+
+// redirectLabelPrefix starts the start URL of every redirect map check.
+const redirectLabelPrefix = "redirects:"
+
+var ErrEmptyRedirectMap = errors.New("no redirects in map")
+
+// Redirect is one row of a redirect map: From should redirect to To.
+type Redirect struct {
+	From string
+	To   string
+}
+
+// RedirectLabel is the start URL recorded for a redirect map check.
+func RedirectLabel(name string) string {
+	if name = strings.TrimSpace(name); name == "" {
+		name = "map"
+	}
+	return redirectLabelPrefix + name
+}
+
+// ParseRedirectMap reads CSV rows of old URL and expected new URL. The new
+// URL may be a path on the old URL's host. Rows without an http(s) URL,
+// such as headers, are skipped.
+func ParseRedirectMap(r io.Reader) ([]Redirect, error) {
+	var redirects []Redirect
+	seen := make(map[string]bool)
+
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		reader := csv.NewReader(strings.NewReader(line))
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		fields, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		from := -1
+		for i, f := range fields {
+			if isHTTPURL(strings.TrimSpace(f)) {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			continue
+		}
+
+		rd := Redirect{From: strings.TrimSpace(fields[from])}
+		if from+1 < len(fields) {
+			rd.To = strings.TrimSpace(fields[from+1])
+		}
+		if rd.To == "" {
+			return nil, fmt.Errorf("line %d: no new URL for %s", n, rd.From)
+		}
+		if !isHTTPURL(rd.To) {
+			base, err := url.Parse(rd.From)
+			ref, refErr := url.Parse(rd.To)
+			if err != nil || refErr != nil || !strings.HasPrefix(rd.To, "/") {
+				return nil, fmt.Errorf("line %d: %q is neither a URL nor a path", n, rd.To)
+			}
+			rd.To = base.ResolveReference(ref).String()
+		}
+
+		if seen[rd.From] {
+			continue
+		}
+		seen[rd.From] = true
+		redirects = append(redirects, rd)
+		if len(redirects) > maxListURLs {
+			return nil, ErrListTooLong
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(redirects) == 0 {
+		return nil, ErrEmptyRedirectMap
+	}
+	return redirects, nil
+}
+
+// VerifyRedirects requests every old URL and follows its redirects one by
+// one. A redirect passes when it lands on the expected URL within maxHops
+// redirects; temporary redirects on the way are reported as warnings. The
+// outcome is recorded as a scan of RedirectLabel(name).
+func (s *Service) VerifyRedirects(name string, redirects []Redirect, maxHops int, userID int32, opts ScanOptions) (*Report, error) {
+	label := RedirectLabel(name)
+	report, err := s.verifyRedirects(label, redirects, maxHops, userID, opts)
+	s.notify(userID, label, report, err)
+	return report, err
+}
+
+func (s *Service) verifyRedirects(label string, redirects []Redirect, maxHops int, userID int32, opts ScanOptions) (*Report, error) {
+	if len(redirects) == 0 {
+		return nil, ErrEmptyRedirectMap
+	}
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+
+	baseURL, err := url.Parse(redirects[0].From)
+	if err != nil {
+		baseURL = &url.URL{}
+	}
+	session, err := s.newSession(userID, baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scanID, err := s.createScan(context.Background(), userID, label, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]string, len(redirects))
+	from := make([]string, 0, len(redirects))
+	for _, rd := range redirects {
+		expected[rd.From] = rd.To
+		from = append(from, rd.From)
+	}
+
+	// Each hop is requested on its own, so the chain can be inspected.
+	client := *session.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	s.checkEach(from, session, func(link string) *ScanResult {
+		result := verifyRedirect(link, expected[link], maxHops, session, &client)
+		session.applyRules(result)
+		return result
+	})
+	log.Printf("Redirect check %s completed. Checked %d redirects", label, session.resultCount())
+
+	dbResults := s.saveResults(userID, label, scanID, session)
+
+	s.finishScan(scanID)
+
+	return &Report{ScanID: scanID.Int32, StartURL: label, Results: dbResults}, nil
+}
+
+// verifyRedirect follows from towards to and describes where it ended.
+func verifyRedirect(from, to string, maxHops int, sess *scanSession, client *http.Client) *ScanResult {
+	result := &ScanResult{URL: from}
+
+	current := from
+	var chain []string
+	var temporary []string
+	for hops := 0; ; hops++ {
+		req, err := http.NewRequest(http.MethodHead, current, nil)
+		if err != nil {
+			result.Category = CategoryInvalidURL
+			result.Error = withChain(err.Error(), chain)
+			return result
+		}
+		req.Header.Set("User-Agent", deadlink.DefaultUserAgent)
+
+		resp, err := client.Do(withCredentials(req, sess.creds, sess.baseURL))
+		if err != nil {
+			result.StatusCode = 0
+			result.Category = deadlink.CategoryForError(err)
+			result.Error = withChain(err.Error(), chain)
+			return result
+		}
+		resp.Body.Close()
+
+		result.StatusCode = resp.StatusCode
+		result.ContentType = resp.Header.Get("Content-Type")
+		chain = append(chain, fmt.Sprintf("%d %s", resp.StatusCode, current))
+
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || resp.StatusCode == http.StatusNotModified {
+			break
+		}
+		if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusPermanentRedirect {
+			temporary = append(temporary, fmt.Sprintf("%d from %s", resp.StatusCode, current))
+		}
+
+		next, err := resp.Location()
+		if err != nil {
+			result.Category = CategoryInvalidURL
+			result.Error = withChain("redirect without a valid Location header", chain)
+			return result
+		}
+		if hops+1 > maxHops {
+			result.Category = CategoryTooManyRedirects
+			result.Error = withChain(fmt.Sprintf("more than %d redirects", maxHops), chain)
+			return result
+		}
+		current = next.String()
+	}
+
+	hops := len(chain) - 1
+	switch category := deadlink.CategoryForStatus(result.StatusCode); {
+	case IsBroken(category):
+		result.Category = category
+		result.Error = withChain(fmt.Sprintf("ends with %d %s", result.StatusCode, http.StatusText(result.StatusCode)), chain)
+		return result
+	case deadlink.NormalizeURL(current) != deadlink.NormalizeURL(to) && hops == 0:
+		result.Category = CategoryWrongRedirect
+		result.Error = "not redirected, expected " + to
+		return result
+	case deadlink.NormalizeURL(current) != deadlink.NormalizeURL(to):
+		result.Category = CategoryWrongRedirect
+		result.Error = withChain(fmt.Sprintf("lands on %s, expected %s", current, to), chain)
+		return result
+	}
+
+	result.Category = CategoryOK
+	if len(temporary) > 0 {
+		result.Warning = "temporary redirect (" + strings.Join(temporary, ", ") + "), use 301 or 308"
+	}
+	return result
+}
+
+func withChain(msg string, chain []string) string {
+	if len(chain) == 0 {
+		return msg
+	}
+	return msg + ": " + strings.Join(chain, " -> ")
+}
@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/pkg/deadlink"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityIgnore  Severity = "ignore"
+)
+
+func (s Severity) Valid() bool {
+	return s == SeverityError || s == SeverityWarning || s == SeverityIgnore
+}
+
+// ScanRules narrow a scan and decide which failures count. URL patterns are
+// regular expressions matched against the full link URL.
+type ScanRules struct {
+	// Hosts are crawled like the start URL's host, e.g. "www.example.com"
+	// next to "example.com".
+	Hosts    []string `json:"hosts,omitempty"`
+	MaxDepth int      `json:"max_depth,omitempty"`
+	// Include, when set, limits checking to matching links. Links matching
+	// Exclude are never checked. Both are reported as skipped.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// Ignore lists links that are checked but never count as broken.
+	Ignore []string `json:"ignore,omitempty"`
+	// Severity maps a status code ("404"), a status class ("5xx") or a
+	// category ("timeout") to the severity of broken links that match.
+	Severity map[string]Severity `json:"severity,omitempty"`
+}
+
+// compiledRules are ScanRules ready to match. A nil *compiledRules applies
+// the defaults.
+type compiledRules struct {
+	maxDepth int
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	ignore   []*regexp.Regexp
+	severity map[string]Severity
+}
+
+// Validate reports the first problem with the rules, naming the offending
+// field.
+func (r *ScanRules) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+func (r *ScanRules) compile() (*compiledRules, error) {
+	if r == nil {
+		return nil, nil
+	}
+	if r.MaxDepth < 0 {
+		return nil, fmt.Errorf("max_depth must not be negative")
+	}
+
+	c := &compiledRules{
+		maxDepth: r.MaxDepth,
+		severity: make(map[string]Severity, len(r.Severity)),
+	}
+	var err error
+	if c.include, err = compilePatterns("include", r.Include); err != nil {
+		return nil, err
+	}
+	if c.exclude, err = compilePatterns("exclude", r.Exclude); err != nil {
+		return nil, err
+	}
+	if c.ignore, err = compilePatterns("ignore", r.Ignore); err != nil {
+		return nil, err
+	}
+
+	for key, severity := range r.Severity {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ValidSeverityKey(key) {
+			return nil, fmt.Errorf("severity: %q is not a 4xx/5xx status code, a status class like \"4xx\" or a broken link category", key)
+		}
+		if !severity.Valid() {
+			return nil, fmt.Errorf("severity %q: %q must be error, warning or ignore", key, severity)
+		}
+		c.severity[key] = severity
+	}
+
+	return c, nil
+}
+
+func compilePatterns(field string, patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: invalid pattern %q: %v", field, i, p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// ValidSeverityKey accepts broken categories and the status codes and
+// classes that produce them.
+func ValidSeverityKey(key string) bool {
+	if c, ok := ParseCategory(key); ok {
+		return IsBroken(c)
+	}
+	if len(key) == 3 && (key[0] == '4' || key[0] == '5') && key[1:] == "xx" {
+		return true
+	}
+	code, err := strconv.Atoi(key)
+	return err == nil && code >= 400 && code <= 599
+}
+
+func matchAny(patterns []*regexp.Regexp, link string) bool {
+	for _, re := range patterns {
+		if re.MatchString(link) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiledRules) depthLimit() int {
+	if c == nil || c.maxDepth == 0 {
+		return deadlink.DefaultMaxDepth
+	}
+	return c.maxDepth
+}
+
+// excluded reports why a link must not be checked, or "" when it may.
+func (c *compiledRules) excluded(link string) string {
+	switch {
+	case c == nil:
+		return ""
+	case matchAny(c.exclude, link):
+		return "excluded by scan rules"
+	case len(c.include) > 0 && !matchAny(c.include, link):
+		return "not included by scan rules"
+	default:
+		return ""
+	}
+}
+
+// apply downgrades broken results that the rules ignore or report as
+// warnings, and reports whether result is now a warning. Ignored results
+// become CategoryIgnored, with the original status in the status code and
+// error; warnings keep their category.
+func (c *compiledRules) apply(result *ScanResult) bool {
+	if c == nil || !IsBroken(result.Category) {
+		return false
+	}
+
+	severity := SeverityError
+	if matchAny(c.ignore, result.URL) {
+		severity = SeverityIgnore
+	} else if s, ok := c.severityFor(result); ok {
+		severity = s
+	}
+
+	switch severity {
+	case SeverityIgnore:
+		result.Category = CategoryIgnored
+	case SeverityWarning:
+		result.Warning = deadlink.JoinWarnings(result.Warning, fmt.Sprintf("%s reported as a warning by scan rules", resultLabel(result)))
+		return true
+	}
+	return false
+}
+
+// severityFor looks up the most specific rule: status code, then status
+// class, then category.
+func (c *compiledRules) severityFor(result *ScanResult) (Severity, bool) {
+	if result.StatusCode > 0 {
+		code := strconv.Itoa(result.StatusCode)
+		if s, ok := c.severity[code]; ok {
+			return s, true
+		}
+		if s, ok := c.severity[code[:1]+"xx"]; ok {
+			return s, true
+		}
+	}
+	s, ok := c.severity[string(result.Category)]
+	return s, ok
+}
+
+func resultLabel(result *ScanResult) string {
+	if result.StatusCode > 0 {
+		return strconv.Itoa(result.StatusCode) + " " + http.StatusText(result.StatusCode)
+	}
+	return strings.ReplaceAll(string(result.Category), "_", " ")
+}
+
+// presetRules returns the rules the user uploaded for the start URL's site,
+// or nil when there are none.
+func (s *Service) presetRules(ctx context.Context, userID int32, startURL string) *ScanRules {
+	if s.queries == nil {
+		return nil
+	}
+
+	preset, err := s.queries.GetSitePreset(ctx, db.GetSitePresetParams{UserID: userID, Site: SiteOf(startURL)})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to load preset for %s: %v", startURL, err)
+		}
+		return nil
+	}
+
+	var rules ScanRules
+	if err := json.Unmarshal([]byte(preset.Rules), &rules); err != nil {
+		log.Printf("Failed to decode preset %d: %v", preset.ID, err)
+		return nil
+	}
+	return &rules
+}
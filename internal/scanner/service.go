@@ -2,303 +2,352 @@ package scanner
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"go-deadlink-scanner/internal/config"
 	db "go-deadlink-scanner/internal/database/sqlc"
-	"io"
+	"go-deadlink-scanner/internal/secret"
+	"go-deadlink-scanner/internal/store"
+	"go-deadlink-scanner/pkg/deadlink"
 	"log"
 	"net/http"
 	"net/url"
-	"strings"
-	"sync"
-	"sync/atomic"
 	"time"
-
-	"golang.org/x/net/html"
 )
 
 type Service struct {
-	queries    *db.Queries
-	maxWorkers int
-	client     *http.Client
-
-	results      map[string]*ScanResult
-	resultsMutex sync.Mutex
-	visited      map[string]bool
-	visitedMutex sync.Mutex
+	store         store.Store
+	queries       *db.Queries
+	maxWorkers    int
+	certWarnDays  int
+	slowThreshold time.Duration
+	slowTTFB      time.Duration
+	client        *http.Client
+	box           *secret.Box
+	transport     TransportOptions
+	cache         *linkCache
+	listeners     listeners
 }
 
-type ScanResult struct {
-	URL        string
-	Status     string
-	StatusCode int
-	Error      string
+type ScanOptions struct {
+	Credentials *Credentials      `json:"credentials,omitempty"`
+	Transport   *TransportOptions `json:"transport,omitempty"`
+	// BypassCache forces external links to be re-checked even when a
+	// fresh result is cached from another scan.
+	BypassCache bool `json:"bypass_cache,omitempty"`
+	// Rules default to the user's preset for the site, if any.
+	Rules *ScanRules `json:"rules,omitempty"`
 }
 
-type linkJob struct {
-	url     string
-	baseURL *url.URL
-	depth   int
+// hasSecrets reports whether the options must be stored encrypted with the
+// scan: credentials, client keys and proxy URLs may all carry secrets.
+func (o ScanOptions) hasSecrets() bool {
+	return !o.Credentials.IsEmpty() || !o.Transport.IsEmpty()
 }
 
-func NewService(queries *db.Queries, maxWorkers int) *Service {
+// NewService records scans in st. queries may be nil, leaving out site
+// presets, conditional page requests and the shared link cache's database.
+func NewService(st store.Store, queries *db.Queries, cfg *config.Config) (*Service, error) {
+	box, err := secret.NewBox(cfg.CredentialsKey)
+	if err != nil {
+		log.Printf("scan credentials disabled: %v", err)
+	}
+
+	transportOpts, err := defaultTransportOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := buildTransport(transportOpts)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
-		queries:    queries,
-		maxWorkers: maxWorkers,
+		store:         st,
+		queries:       queries,
+		maxWorkers:    cfg.MaxScannerWorkers,
+		certWarnDays:  cfg.CertExpiryWarnDays,
+		slowThreshold: cfg.SlowLinkThreshold,
+		slowTTFB:      cfg.SlowTTFBThreshold,
+		box:           box,
+		transport:     transportOpts,
+		cache:         newLinkCache(queries, cfg.LinkCacheTTL),
 		client: &http.Client{
-			Timeout: 5 * time.Second,
+			Transport: transport,
+			Timeout:   5 * time.Second,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= 5 {
-					return fmt.Errorf("stopped after 5 redirects")
+				if len(via) >= deadlink.MaxRedirects {
+					return deadlink.ErrTooManyRedirects
 				}
+				scopeRedirect(req)
 				return nil
 			},
 		},
-		results: make(map[string]*ScanResult),
-		visited: make(map[string]bool),
-	}
+	}, nil
 }
 
-func (s *Service) Scan(startURL string, userID int32) ([]db.Result, error) {
-	s.resultsMutex.Lock()
-	s.results = make(map[string]*ScanResult)
-	s.resultsMutex.Unlock()
+// Report is the outcome of a finished scan.
+type Report struct {
+	ScanID   int32
+	StartURL string
+	Results  []db.Result
+	// Sources maps links to the pages they were found on. Links checked
+	// from a list have none.
+	Sources map[string][]string
+}
 
-	s.visitedMutex.Lock()
-	s.visited = make(map[string]bool)
-	s.visitedMutex.Unlock()
+// Scan crawls startURL and stores the results. Subscribers are notified
+// whether the scan succeeds or fails.
+func (s *Service) Scan(startURL string, userID int32, opts ScanOptions) (*Report, error) {
+	report, err := s.scan(startURL, userID, opts)
+	s.notify(userID, startURL, report, err)
+	return report, err
+}
 
+func (s *Service) scan(startURL string, userID int32, opts ScanOptions) (*Report, error) {
 	baseURL, err := url.Parse(startURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %v", err)
 	}
 
-	jobs := make(chan linkJob, 1000)
-	var wg sync.WaitGroup
-	var activeJobs int32
-
-	for i := 0; i < s.maxWorkers; i++ {
-		wg.Add(1)
-		go s.workerWithJobTracking(i, jobs, &wg, &activeJobs)
+	if opts.Rules == nil {
+		opts.Rules = s.presetRules(context.Background(), userID, startURL)
 	}
 
-	atomic.AddInt32(&activeJobs, 1)
-	jobs <- linkJob{url: startURL, baseURL: baseURL, depth: 0}
+	session, err := s.newSession(userID, baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		for {
-			time.Sleep(100 * time.Millisecond)
-			if atomic.LoadInt32(&activeJobs) == 0 {
-				close(jobs)
-				wg.Wait()
-				return
-			}
-		}
-	}()
+	scanID, err := s.createScan(context.Background(), userID, startURL, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	select {
-	case <-done:
-		log.Printf("Scan completed. Found %d links", len(s.results))
-	case <-time.After(30 * time.Second):
-		log.Printf("Scan timed out after 30 seconds. Found %d links so far", len(s.results))
-		close(jobs)
-		wg.Wait()
+	if _, err := session.crawler.Crawl(context.Background()); err != nil {
+		s.deleteScan(scanID)
+		return nil, err
 	}
 
+	dbResults := s.saveResults(userID, startURL, scanID, session)
+	s.saveOccurrences(scanID, session)
+
+	s.finishScan(scanID)
+
+	return &Report{ScanID: scanID.Int32, StartURL: startURL, Results: dbResults, Sources: session.sourceMap()}, nil
+}
+
+// saveResults converts the results of a scan and stores them with the scan
+// when it was recorded.
+func (s *Service) saveResults(userID int32, startURL string, scanID sql.NullInt32, sess *scanSession) []db.Result {
+	sess.resultsMutex.Lock()
 	var dbResults []db.Result
-	s.resultsMutex.Lock()
-	for url, result := range s.results {
-		dbResult := db.Result{
-			UserID:    userID,
-			PageUrl:   startURL,
-			LinkUrl:   url,
-			Status:    result.Status,
-			CheckedAt: time.Now(),
-		}
-		dbResults = append(dbResults, dbResult)
-
-		if s.queries != nil {
-			_, err := s.queries.CreateResult(context.Background(), db.CreateResultParams{
-				UserID:  userID,
-				PageUrl: startURL,
-				LinkUrl: url,
-				Status:  result.Status,
-			})
-			if err != nil {
-				log.Printf("Failed to save result for %s: %v", url, err)
-			}
+	var params []db.CreateResultParams
+	for url, result := range sess.results {
+		p := newResultParams(userID, startURL, scanID, url, result, sess.severity(url))
+		params = append(params, p)
+		dbResults = append(dbResults, resultFromParams(p, time.Now()))
+	}
+	sess.resultsMutex.Unlock()
+
+	if scanID.Valid {
+		if err := s.store.SaveResults(context.Background(), params); err != nil {
+			log.Printf("Failed to save results of scan %d: %v", scanID.Int32, err)
 		}
 	}
-	s.resultsMutex.Unlock()
+	return dbResults
+}
 
-	return dbResults, nil
+func newResultParams(userID int32, startURL string, scanID sql.NullInt32, link string, result *ScanResult, severity Severity) db.CreateResultParams {
+	return db.CreateResultParams{
+		UserID:      userID,
+		PageUrl:     startURL,
+		LinkUrl:     link,
+		Warning:     result.Warning,
+		ScanID:      scanID,
+		Category:    string(result.Category),
+		StatusCode:  int32(result.StatusCode),
+		ContentType: result.ContentType,
+		ErrorDetail: result.Error,
+		DnsMs:       int32(result.Timing.DNS.Milliseconds()),
+		ConnectMs:   int32(result.Timing.Connect.Milliseconds()),
+		TlsMs:       int32(result.Timing.TLS.Milliseconds()),
+		TtfbMs:      int32(result.Timing.TTFB.Milliseconds()),
+		TotalMs:     int32(result.Timing.Total.Milliseconds()),
+		RemoteIp:    result.RemoteIP,
+		Cached:      result.Cached,
+		Severity:    string(severity),
+	}
 }
 
-func (s *Service) workerWithJobTracking(id int, jobs chan linkJob, wg *sync.WaitGroup, activeJobs *int32) {
-	defer wg.Done()
+// resultFromParams is the row a result reads back as once stored.
+func resultFromParams(p db.CreateResultParams, checkedAt time.Time) db.Result {
+	return db.Result{
+		UserID:      p.UserID,
+		PageUrl:     p.PageUrl,
+		LinkUrl:     p.LinkUrl,
+		Warning:     p.Warning,
+		CheckedAt:   checkedAt,
+		ScanID:      p.ScanID,
+		Category:    p.Category,
+		StatusCode:  p.StatusCode,
+		ContentType: p.ContentType,
+		ErrorDetail: p.ErrorDetail,
+		DnsMs:       p.DnsMs,
+		ConnectMs:   p.ConnectMs,
+		TlsMs:       p.TlsMs,
+		TtfbMs:      p.TtfbMs,
+		TotalMs:     p.TotalMs,
+		RemoteIp:    p.RemoteIp,
+		Cached:      p.Cached,
+		Severity:    p.Severity,
+	}
+}
 
-	for job := range jobs {
-		s.visitedMutex.Lock()
-		if s.visited[job.url] {
-			s.visitedMutex.Unlock()
-			atomic.AddInt32(activeJobs, -1)
-			continue
-		}
-		s.visited[job.url] = true
-		s.visitedMutex.Unlock()
-
-		log.Printf("Worker %d: checking %s (depth: %d)", id, job.url, job.depth)
-
-		result := s.checkLink(job.url)
-
-		s.resultsMutex.Lock()
-		s.results[job.url] = result
-		s.resultsMutex.Unlock()
-
-		if result.StatusCode == 200 && job.depth < 10 && strings.Contains(result.Status, "text/html") {
-			links := s.extractLinks(job.url, job.baseURL)
-
-			newJobsAdded := 0
-			for _, link := range links {
-				s.visitedMutex.Lock()
-				if !s.visited[link] {
-					select {
-					case jobs <- linkJob{url: link, baseURL: job.baseURL, depth: job.depth + 1}:
-						newJobsAdded++
-					default:
-						// Channel is full, skip this link
-					}
-				}
-				s.visitedMutex.Unlock()
-			}
+// saveOccurrences stores the pages each link was found on, so a recheck
+// knows which pages to fetch again.
+func (s *Service) saveOccurrences(scanID sql.NullInt32, sess *scanSession) {
+	if !scanID.Valid {
+		return
+	}
 
-			if newJobsAdded > 0 {
-				atomic.AddInt32(activeJobs, int32(newJobsAdded))
-			}
+	sess.sourcesMutex.Lock()
+	var occurrences []db.CreateLinkOccurrenceParams
+	for link, pages := range sess.sources {
+		for _, page := range pages {
+			occurrences = append(occurrences, db.CreateLinkOccurrenceParams{ScanID: scanID.Int32, LinkUrl: link, PageUrl: page})
 		}
+	}
+	sess.sourcesMutex.Unlock()
 
-		atomic.AddInt32(activeJobs, -1)
+	if err := s.store.SaveLinkOccurrences(context.Background(), occurrences); err != nil {
+		log.Printf("Failed to save link occurrences of scan %d: %v", scanID.Int32, err)
 	}
 }
 
-func (s *Service) checkLink(linkURL string) *ScanResult {
-	result := &ScanResult{
-		URL:    linkURL,
-		Status: "unknown",
+func (s *Service) finishScan(scanID sql.NullInt32) {
+	if !scanID.Valid {
+		return
+	}
+	if err := s.store.FinishScan(context.Background(), scanID.Int32); err != nil {
+		log.Printf("Failed to mark scan %d finished: %v", scanID.Int32, err)
 	}
+}
 
-	req, err := http.NewRequest("HEAD", linkURL, nil)
-	if err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("invalid request: %v", err)
-		return result
+// deleteScan drops the record of a scan that failed, so it is neither listed
+// nor compared against.
+func (s *Service) deleteScan(scanID sql.NullInt32) {
+	if !scanID.Valid {
+		return
 	}
+	if err := s.store.DeleteScan(context.Background(), scanID.Int32); err != nil {
+		log.Printf("Failed to delete failed scan %d: %v", scanID.Int32, err)
+	}
+}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; DeadLinkChecker/1.0)")
+// createScan records the scan and its options. A persistent store gets them
+// encrypted with the configured key, and scans that carry secrets are
+// refused without one. The memory store never leaves the process, so no key
+// is needed.
+func (s *Service) createScan(ctx context.Context, userID int32, startURL string, opts ScanOptions) (sql.NullInt32, error) {
+	options, err := s.encodeOptions(opts)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
 
-	resp, err := s.client.Do(req)
+	scan, err := s.store.CreateScan(ctx, db.CreateScanParams{
+		UserID:      userID,
+		StartUrl:    startURL,
+		Credentials: options,
+		Site:        SiteOf(startURL),
+	})
 	if err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("request failed: %v", err)
-		return result
-	}
-	defer resp.Body.Close()
-
-	result.StatusCode = resp.StatusCode
-
-	switch {
-	case resp.StatusCode >= 200 && resp.StatusCode < 300:
-		result.Status = fmt.Sprintf("ok (%s)", resp.Header.Get("Content-Type"))
-	case resp.StatusCode == 404:
-		result.Status = "404 Not Found"
-		result.Error = "Page not found"
-	case resp.StatusCode >= 500:
-		result.Status = fmt.Sprintf("Server Error (%d)", resp.StatusCode)
-		result.Error = "Internal server error"
-	case resp.StatusCode >= 400:
-		result.Status = fmt.Sprintf("Client Error (%d)", resp.StatusCode)
-		result.Error = "Client error"
-	default:
-		result.Status = fmt.Sprintf("Redirect (%d)", resp.StatusCode)
+		return sql.NullInt32{}, fmt.Errorf("create scan: %w", err)
 	}
 
-	return result
+	return sql.NullInt32{Int32: scan.ID, Valid: true}, nil
 }
 
-func (s *Service) extractLinks(pageURL string, baseURL *url.URL) []string {
-	resp, err := s.client.Get(pageURL)
-	if err != nil {
-		log.Printf("Failed to get page %s: %v", pageURL, err)
-		return nil
+// SealOptions encrypts options for storage. It returns nil when the options
+// carry no secrets and fails when they do but no key is configured.
+func (s *Service) SealOptions(opts ScanOptions) ([]byte, error) {
+	if !opts.hasSecrets() {
+		return nil, nil
+	}
+	if s.box == nil {
+		return nil, fmt.Errorf("cannot scan with credentials: %w", secret.ErrNoKey)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	plain, err := json.Marshal(opts)
 	if err != nil {
-		log.Printf("Failed to read body from %s: %v", pageURL, err)
-		return nil
+		return nil, err
 	}
-
-	doc, err := html.Parse(strings.NewReader(string(body)))
+	sealed, err := s.box.Seal(plain)
 	if err != nil {
-		log.Printf("Failed to parse HTML from %s: %v", pageURL, err)
-		return nil
+		return nil, fmt.Errorf("encrypt credentials: %w", err)
 	}
+	return sealed, nil
+}
 
-	var links []string
-	s.traverseHTML(doc, baseURL, &links)
+// OpenOptions reverses SealOptions.
+func (s *Service) OpenOptions(sealed []byte) (ScanOptions, error) {
+	var opts ScanOptions
+	if len(sealed) == 0 {
+		return opts, nil
+	}
+	if s.box == nil {
+		return opts, secret.ErrNoKey
+	}
 
-	linkMap := make(map[string]bool)
-	var uniqueLinks []string
-	for _, link := range links {
-		if !linkMap[link] {
-			linkMap[link] = true
-			uniqueLinks = append(uniqueLinks, link)
-		}
+	plain, err := s.box.Open(sealed)
+	if err != nil {
+		return opts, fmt.Errorf("decrypt scan options: %w", err)
 	}
 
-	return uniqueLinks
+	if err := json.Unmarshal(plain, &opts); err != nil {
+		return opts, err
+	}
+	return opts, nil
 }
 
-func (s *Service) traverseHTML(n *html.Node, baseURL *url.URL, links *[]string) {
-	if n.Type == html.ElementNode && n.Data == "a" {
-		for _, attr := range n.Attr {
-			if attr.Key == "href" {
-				link := s.resolveURL(attr.Val, baseURL)
-				if link != "" && s.isInternalLink(link, baseURL) {
-					*links = append(*links, link)
-				}
-				break
-			}
-		}
+func (s *Service) encodeOptions(opts ScanOptions) ([]byte, error) {
+	if s.store.Persistent() {
+		return s.SealOptions(opts)
 	}
-
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		s.traverseHTML(c, baseURL, links)
+	if !opts.hasSecrets() {
+		return nil, nil
 	}
+	return json.Marshal(opts)
 }
 
-func (s *Service) resolveURL(href string, baseURL *url.URL) string {
-	if href == "" || strings.HasPrefix(href, "#") ||
-		strings.HasPrefix(href, "mailto:") || strings.HasPrefix(href, "tel:") {
-		return ""
+// StoredOptions decodes the options stored with a scan, e.g. to re-run it.
+func (s *Service) StoredOptions(scan db.Scan) (ScanOptions, error) {
+	if s.store.Persistent() {
+		return s.OpenOptions(scan.Credentials)
 	}
 
-	linkURL, err := url.Parse(href)
-	if err != nil {
-		return ""
+	var opts ScanOptions
+	if len(scan.Credentials) == 0 {
+		return opts, nil
 	}
-
-	resolvedURL := baseURL.ResolveReference(linkURL)
-	return resolvedURL.String()
+	err := json.Unmarshal(scan.Credentials, &opts)
+	return opts, err
 }
 
-func (s *Service) isInternalLink(link string, baseURL *url.URL) bool {
-	linkURL, err := url.Parse(link)
-	if err != nil {
-		return false
-	}
+func (s *Service) ListResults(ctx context.Context, userID int32, pageURL string, category Category, limit, offset int32) ([]db.Result, error) {
+	return s.store.ListResults(ctx, db.ListResultsByUserFilteredParams{
+		UserID:    userID,
+		PageUrl:   pageURL,
+		Category:  string(category),
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+}
 
-	return linkURL.Host == baseURL.Host
+// checkLink checks a single link and applies the scan rules to the result.
+func (s *Service) checkLink(linkURL string, sess *scanSession) *ScanResult {
+	result := sess.crawler.Check(context.Background(), linkURL)
+	sess.applyRules(result)
+	return result
 }
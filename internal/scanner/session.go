@@ -0,0 +1,244 @@
+package scanner
+
+import (
+	"database/sql"
+	"fmt"
+	"go-deadlink-scanner/pkg/deadlink"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"sync"
+)
+
+// scanSession holds the request state and results shared by all workers of
+// one scan, so concurrent scans do not interfere with each other.
+type scanSession struct {
+	userID   int32
+	baseURL  *url.URL
+	creds    *Credentials
+	client   *http.Client
+	login    *loginState
+	useCache bool
+	rules    *compiledRules
+	crawler  *deadlink.Crawler
+
+	results map[string]*ScanResult
+	// warned holds the links whose failures the rules report as warnings.
+	warned       map[string]bool
+	resultsMutex sync.Mutex
+	// sources maps every link to the pages it was found on.
+	sources      map[string][]string
+	sourcesMutex sync.Mutex
+}
+
+func (s *Service) newSession(userID int32, baseURL *url.URL, opts ScanOptions) (*scanSession, error) {
+	creds := opts.Credentials
+	sess := &scanSession{
+		userID:  userID,
+		baseURL: baseURL,
+		creds:   creds,
+		client:  s.client,
+		results: make(map[string]*ScanResult),
+		warned:  make(map[string]bool),
+		sources: make(map[string][]string),
+		// Results depend on the network path, so scans with their own
+		// transport settings neither read nor fill the shared cache.
+		useCache: s.cache.enabled() && !opts.BypassCache && opts.Transport.IsEmpty(),
+	}
+
+	rules, err := opts.Rules.compile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid scan rules: %w", err)
+	}
+	sess.rules = rules
+
+	if !opts.Transport.IsEmpty() {
+		transport, err := buildTransport(s.transport.merge(opts.Transport))
+		if err != nil {
+			return nil, err
+		}
+		client := *s.client
+		client.Transport = transport
+		sess.client = &client
+	}
+
+	if creds != nil && creds.Login != nil {
+		if err := sess.startLogin(creds.Login); err != nil {
+			return nil, err
+		}
+	}
+
+	sess.crawler, err = deadlink.New(baseURL.String(), s.crawlerOptions(sess, opts))
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Crawl is an unrecorded crawl for callers that run the crawler themselves:
+// pass Options to deadlink.Crawl, then read the outcome with Report.
+type Crawl struct {
+	// Options use the service's client and cache and the credentials and
+	// rules of the scan. OnResult and OnLinks collect the report and must
+	// be kept.
+	Options deadlink.Options
+
+	service  *Service
+	startURL string
+	sess     *scanSession
+}
+
+// NewCrawl sets up a crawl of startURL, logging in first when opts has a
+// login step.
+func (s *Service) NewCrawl(startURL string, opts ScanOptions) (*Crawl, error) {
+	baseURL, err := url.Parse(startURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	sess, err := s.newSession(0, baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Crawl{Options: s.crawlerOptions(sess, opts), service: s, startURL: startURL, sess: sess}, nil
+}
+
+// Report returns the results of the crawl once it has run.
+func (c *Crawl) Report() *Report {
+	results := c.service.saveResults(0, c.startURL, sql.NullInt32{}, c.sess)
+	return &Report{StartURL: c.startURL, Results: results, Sources: c.sess.sourceMap()}
+}
+
+// crawlerOptions hands the session's client, credentials, rules and caches
+// to the crawler, which stores its results in the session.
+func (s *Service) crawlerOptions(sess *scanSession, opts ScanOptions) deadlink.Options {
+	o := deadlink.Options{
+		Client:        sess.client,
+		Do:            sess.do,
+		Workers:       s.maxWorkers,
+		MaxDepth:      sess.rules.depthLimit(),
+		CertWarnDays:  s.certWarnDays,
+		SlowThreshold: s.slowThreshold,
+		SlowTTFB:      s.slowTTFB,
+		Skip:          sess.skip,
+		OnResult: func(result *ScanResult) {
+			sess.applyRules(result)
+			sess.resultsMutex.Lock()
+			sess.results[result.URL] = result
+			sess.resultsMutex.Unlock()
+		},
+		OnLinks: func(page string, links []string) {
+			for _, link := range links {
+				sess.addSource(link, page)
+			}
+		},
+		Logf: log.Printf,
+	}
+	if opts.Rules != nil {
+		o.Hosts = opts.Rules.Hosts
+	}
+	if sess.useCache {
+		o.Cache = s.cache
+	}
+	if s.queries != nil {
+		o.Pages = &pageStore{queries: s.queries, userID: sess.userID}
+	}
+	return o
+}
+
+// skip reports why a link must not be requested: it would end the login
+// session or the scan rules leave it out.
+func (sess *scanSession) skip(link string) string {
+	if u, err := url.Parse(link); err == nil && sess.skipLink(u) {
+		return "not requested to keep the login session"
+	}
+	return sess.rules.excluded(link)
+}
+
+// startLogin logs in with a cookie jar of the session's own.
+func (sess *scanSession) startLogin(step *LoginStep) error {
+	loginURL, err := url.Parse(step.URL)
+	if err != nil || loginURL.Host == "" {
+		return fmt.Errorf("invalid login URL %q", step.URL)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	client := *sess.client
+	client.Jar = jar
+	sess.client = &client
+	sess.login = &loginState{step: step, loginURL: loginURL}
+
+	if err := sess.relogin(0); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	return nil
+}
+
+// applyRules applies the scan rules to result and remembers whether they
+// report it as a warning.
+func (sess *scanSession) applyRules(result *ScanResult) {
+	warned := sess.rules.apply(result)
+	sess.resultsMutex.Lock()
+	defer sess.resultsMutex.Unlock()
+	if warned {
+		sess.warned[result.URL] = true
+	} else {
+		delete(sess.warned, result.URL)
+	}
+}
+
+// severity is SeverityWarning for links the rules report as warnings and
+// empty otherwise. The caller holds resultsMutex or the checks are done.
+func (sess *scanSession) severity(link string) Severity {
+	if sess.warned[link] {
+		return SeverityWarning
+	}
+	return ""
+}
+
+func (sess *scanSession) resultCount() int {
+	sess.resultsMutex.Lock()
+	defer sess.resultsMutex.Unlock()
+	return len(sess.results)
+}
+
+func (sess *scanSession) addSource(link, page string) {
+	sess.sourcesMutex.Lock()
+	defer sess.sourcesMutex.Unlock()
+	if !slices.Contains(sess.sources[link], page) {
+		sess.sources[link] = append(sess.sources[link], page)
+	}
+}
+
+// sourceMap returns a copy of the pages each link was found on.
+func (sess *scanSession) sourceMap() map[string][]string {
+	sess.sourcesMutex.Lock()
+	defer sess.sourcesMutex.Unlock()
+	sources := make(map[string][]string, len(sess.sources))
+	for link, pages := range sess.sources {
+		sources[link] = slices.Clone(pages)
+	}
+	return sources
+}
+
+// do sends req with the scan's credentials. If the response shows that the
+// session was logged out, it logs in again and retries the request once.
+func (sess *scanSession) do(req *http.Request) (*http.Response, error) {
+	req = withCredentials(req, sess.creds, sess.baseURL)
+
+	gen := sess.loginGeneration()
+	resp, err := sess.client.Do(req)
+	if err != nil || !sess.isLoggedOut(req.URL, resp) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if err := sess.relogin(gen); err != nil {
+		return nil, fmt.Errorf("re-login failed: %w", err)
+	}
+	return sess.client.Do(req.Clone(req.Context()))
+}
@@ -0,0 +1,206 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go-deadlink-scanner/internal/config"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportOptions control how scan requests reach the network. The service
+// defaults come from config.Config; a scan may override any non-empty field.
+type TransportOptions struct {
+	ProxyURL      string   `json:"proxy_url,omitempty"`
+	NoProxy       []string `json:"no_proxy,omitempty"`
+	CACertsPEM    string   `json:"ca_certs_pem,omitempty"`
+	ClientCertPEM string   `json:"client_cert_pem,omitempty"`
+	ClientKeyPEM  string   `json:"client_key_pem,omitempty"`
+	// HostOverrides maps "host" or "host:port" to the IP to connect to,
+	// like curl --resolve. TLS still verifies against the original host.
+	// Requests through a proxy are resolved by the proxy, so overrides
+	// cannot be combined with ProxyURL.
+	HostOverrides map[string]string `json:"host_overrides,omitempty"`
+	// DNSServer is a "host:port" nameserver used instead of the system one.
+	DNSServer string `json:"dns_server,omitempty"`
+}
+
+func (o *TransportOptions) IsEmpty() bool {
+	return o == nil || (o.ProxyURL == "" && len(o.NoProxy) == 0 && o.CACertsPEM == "" &&
+		o.ClientCertPEM == "" && o.ClientKeyPEM == "" && len(o.HostOverrides) == 0 && o.DNSServer == "")
+}
+
+// merge returns o with every non-empty field of override applied. Extra CA
+// bundles are added to the defaults rather than replacing them.
+func (o TransportOptions) merge(override *TransportOptions) TransportOptions {
+	if override == nil {
+		return o
+	}
+	if override.ProxyURL != "" {
+		o.ProxyURL = override.ProxyURL
+	}
+	if len(override.NoProxy) > 0 {
+		o.NoProxy = override.NoProxy
+	}
+	if override.CACertsPEM != "" {
+		o.CACertsPEM = strings.TrimSpace(o.CACertsPEM + "\n" + override.CACertsPEM)
+	}
+	if override.ClientCertPEM != "" || override.ClientKeyPEM != "" {
+		o.ClientCertPEM = override.ClientCertPEM
+		o.ClientKeyPEM = override.ClientKeyPEM
+	}
+	if len(override.HostOverrides) > 0 {
+		merged := make(map[string]string, len(o.HostOverrides)+len(override.HostOverrides))
+		for host, ip := range o.HostOverrides {
+			merged[host] = ip
+		}
+		for host, ip := range override.HostOverrides {
+			merged[host] = ip
+		}
+		o.HostOverrides = merged
+	}
+	if override.DNSServer != "" {
+		o.DNSServer = override.DNSServer
+	}
+	return o
+}
+
+// defaultTransportOptions reads the CA bundles and client certificate files
+// named in the configuration.
+func defaultTransportOptions(cfg *config.Config) (TransportOptions, error) {
+	opts := TransportOptions{ProxyURL: cfg.ProxyURL, NoProxy: cfg.NoProxy, DNSServer: cfg.DNSServer}
+	certFile, keyFile := cfg.ClientCertFile, cfg.ClientKeyFile
+
+	overrides, err := ParseHostOverrides(strings.Join(cfg.HostOverrides, "\n"))
+	if err != nil {
+		return opts, err
+	}
+	opts.HostOverrides = overrides
+
+	var bundles []string
+	for _, path := range cfg.CAFiles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return opts, fmt.Errorf("read CA bundle: %w", err)
+		}
+		bundles = append(bundles, string(pem))
+	}
+	opts.CACertsPEM = strings.Join(bundles, "\n")
+
+	if (certFile == "") != (keyFile == "") {
+		return opts, errors.New("client certificate and key must be configured together")
+	}
+	if certFile != "" {
+		cert, err := os.ReadFile(certFile)
+		if err != nil {
+			return opts, fmt.Errorf("read client certificate: %w", err)
+		}
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return opts, fmt.Errorf("read client key: %w", err)
+		}
+		opts.ClientCertPEM, opts.ClientKeyPEM = string(cert), string(key)
+	}
+
+	return opts, nil
+}
+
+func buildTransport(opts TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.ProxyURL != "" && len(opts.HostOverrides) > 0 {
+		return nil, errors.New("host overrides cannot be combined with a proxy")
+	}
+
+	if opts.ProxyURL != "" {
+		if _, err := url.Parse(opts.ProxyURL); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  opts.ProxyURL,
+			HTTPSProxy: opts.ProxyURL,
+			NoProxy:    strings.Join(opts.NoProxy, ","),
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	tlsConfig := &tls.Config{}
+	if opts.CACertsPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(opts.CACertsPEM)) {
+			return nil, errors.New("no certificates found in CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.ClientCertPEM != "" || opts.ClientKeyPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(opts.ClientCertPEM), []byte(opts.ClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if opts.DNSServer != "" {
+		server := opts.DNSServer
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+	overrides := opts.HostOverrides
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			host = strings.ToLower(host)
+			if ip, ok := overrides[net.JoinHostPort(host, port)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			} else if ip, ok := overrides[host]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return transport, nil
+}
+
+// ParseHostOverrides parses "host=ip" or "host:port=ip" entries, one per
+// line.
+func ParseHostOverrides(raw string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		host, ip, ok := strings.Cut(line, "=")
+		host, ip = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(ip)
+		if !ok || host == "" || net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid host override %q, expected \"host=ip\" or \"host:port=ip\"", line)
+		}
+		overrides[host] = ip
+	}
+	return overrides, nil
+}
@@ -0,0 +1,216 @@
+package schedule
+
+import (
+	"database/sql"
+	"errors"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/internal/secret"
+	scheduleui "go-deadlink-scanner/internal/templates/schedule"
+	"go-deadlink-scanner/internal/ui"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) SchedulesPage(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	schedules, err := h.service.List(c.Context(), userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading schedules: " + err.Error())
+	}
+
+	return ui.RenderComponent(c, scheduleui.SchedulesPage(toRows(schedules)))
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+func (h *Handler) Create(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	opts, err := scanner.ParseScanOptions(c)
+	if err != nil {
+		return h.fail(c, userId, fiber.StatusBadRequest, err)
+	}
+
+	cronExpr := strings.TrimSpace(c.FormValue("cron"))
+	switch frequency := c.FormValue("frequency"); frequency {
+	case "hourly", "daily", "weekly":
+		cronExpr = "@" + frequency
+	case "", "custom":
+	default:
+		return h.fail(c, userId, fiber.StatusBadRequest, errors.New("unknown frequency "+frequency))
+	}
+
+	_, err = h.service.Create(c.Context(), userId, Input{
+		StartURL: strings.TrimSpace(c.FormValue("url")),
+		CronExpr: cronExpr,
+		Timezone: strings.TrimSpace(c.FormValue("timezone")),
+		Options:  opts,
+	})
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, ErrInvalidSchedule) || errors.Is(err, secret.ErrNoKey) {
+			status = fiber.StatusBadRequest
+		}
+		return h.fail(c, userId, status, err)
+	}
+
+	if !ui.IsHX(c) {
+		c.Status(fiber.StatusCreated)
+	}
+	return h.respond(c, userId, nil)
+}
+
+func (h *Handler) SetEnabled(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid schedule id",
+		})
+	}
+
+	if _, err := h.service.SetEnabled(c.Context(), userId, int32(id), c.FormValue("enabled") == "true"); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.fail(c, userId, fiber.StatusNotFound, errors.New("schedule not found"))
+		}
+		return h.fail(c, userId, fiber.StatusInternalServerError, err)
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid schedule id",
+		})
+	}
+
+	if err := h.service.Delete(c.Context(), userId, int32(id)); err != nil {
+		return h.fail(c, userId, fiber.StatusInternalServerError, err)
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+// fail reports err as JSON, or re-renders the schedule list with the error
+// for htmx requests, which do not swap error responses.
+func (h *Handler) fail(c *fiber.Ctx, userID int32, status int, err error) error {
+	if ui.IsHX(c) {
+		return h.respond(c, userID, []string{err.Error()})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func (h *Handler) respond(c *fiber.Ctx, userID int32, errs []string) error {
+	schedules, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if ui.IsHX(c) {
+		return ui.RenderComponent(c, scheduleui.ScheduleList(toRows(schedules), errs))
+	}
+
+	items := make([]fiber.Map, 0, len(schedules))
+	for _, s := range schedules {
+		item := fiber.Map{
+			"id":           s.ID,
+			"start_url":    s.StartUrl,
+			"cron":         s.CronExpr,
+			"timezone":     s.Timezone,
+			"bypass_cache": s.BypassCache,
+			"enabled":      s.Enabled,
+			"running":      s.RunningSince.Valid,
+			"next_run_at":  s.NextRunAt,
+			"last_run_at":  nil,
+			"last_scan_id": nil,
+			"last_error":   s.LastError,
+		}
+		if s.LastRunAt.Valid {
+			item["last_run_at"] = s.LastRunAt.Time
+		}
+		if s.LastScanID.Valid {
+			item["last_scan_id"] = s.LastScanID.Int32
+		}
+		items = append(items, item)
+	}
+	return c.JSON(fiber.Map{"schedules": items})
+}
+
+func toRows(schedules []db.Schedule) []scheduleui.ScheduleRow {
+	var rows []scheduleui.ScheduleRow
+	for _, s := range schedules {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		row := scheduleui.ScheduleRow{
+			ID:        s.ID,
+			StartURL:  s.StartUrl,
+			CronExpr:  s.CronExpr,
+			Timezone:  s.Timezone,
+			Enabled:   s.Enabled,
+			Running:   s.RunningSince.Valid,
+			NextRun:   s.NextRunAt.In(loc).Format("2006-01-02 15:04 MST"),
+			LastError: s.LastError,
+		}
+		if s.LastRunAt.Valid {
+			row.LastRun = s.LastRunAt.Time.In(loc).Format("2006-01-02 15:04 MST")
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,235 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/scanner"
+	"log"
+	"net/url"
+	"time"
+
+	// Embedded zone database so timezones resolve in minimal containers.
+	_ "time/tzdata"
+)
+
+// staleAfter releases schedules whose run was claimed but never finished,
+// e.g. because the server stopped mid-scan. Running scans refresh their
+// claim every claimRefresh, so long crawls are not released.
+const (
+	staleAfter   = 15 * time.Minute
+	claimRefresh = staleAfter / 3
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+type Service struct {
+	queries  *db.Queries
+	scanner  *scanner.Service
+	interval time.Duration
+}
+
+// Input is a schedule as submitted by the user.
+type Input struct {
+	StartURL string
+	CronExpr string
+	Timezone string
+	Options  scanner.ScanOptions
+}
+
+func NewService(queries *db.Queries, scannerService *scanner.Service, interval time.Duration) *Service {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Service{queries: queries, scanner: scannerService, interval: interval}
+}
+
+func (s *Service) Create(ctx context.Context, userID int32, in Input) (db.Schedule, error) {
+	u, err := url.Parse(in.StartURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return db.Schedule{}, fmt.Errorf("%w: start URL must be an absolute http(s) URL", ErrInvalidSchedule)
+	}
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+
+	next, err := nextRun(in.CronExpr, in.Timezone, time.Now())
+	if err != nil {
+		return db.Schedule{}, err
+	}
+
+	sealed, err := s.scanner.SealOptions(in.Options)
+	if err != nil {
+		return db.Schedule{}, err
+	}
+
+	return s.queries.CreateSchedule(ctx, db.CreateScheduleParams{
+		UserID:      userID,
+		StartUrl:    in.StartURL,
+		CronExpr:    in.CronExpr,
+		Timezone:    in.Timezone,
+		Options:     sealed,
+		BypassCache: in.Options.BypassCache,
+		NextRunAt:   next,
+	})
+}
+
+func (s *Service) List(ctx context.Context, userID int32) ([]db.Schedule, error) {
+	return s.queries.ListSchedulesByUser(ctx, userID)
+}
+
+// SetEnabled pauses or resumes a schedule. Resuming recomputes the next run
+// from now instead of catching up on the runs missed while paused.
+func (s *Service) SetEnabled(ctx context.Context, userID, id int32, enabled bool) (db.Schedule, error) {
+	sched, err := s.queries.GetScheduleByID(ctx, db.GetScheduleByIDParams{ID: id, UserID: userID})
+	if err != nil {
+		return db.Schedule{}, err
+	}
+
+	next := sched.NextRunAt
+	if enabled && !sched.Enabled {
+		if next, err = nextRun(sched.CronExpr, sched.Timezone, time.Now()); err != nil {
+			return db.Schedule{}, err
+		}
+	}
+
+	return s.queries.SetScheduleEnabled(ctx, db.SetScheduleEnabledParams{
+		ID:        id,
+		UserID:    userID,
+		Enabled:   enabled,
+		NextRunAt: next,
+	})
+}
+
+func (s *Service) Delete(ctx context.Context, userID, id int32) error {
+	return s.queries.DeleteSchedule(ctx, db.DeleteScheduleParams{ID: id, UserID: userID})
+}
+
+// Run starts due schedules until ctx is cancelled. State lives in the
+// database, so runs missed while the server was down start on the first tick
+// after a restart.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) runDue(ctx context.Context) {
+	now := time.Now().UTC()
+
+	stale := sql.NullTime{Time: now.Add(-staleAfter), Valid: true}
+	if err := s.queries.ReleaseStaleSchedules(ctx, stale); err != nil {
+		log.Printf("Failed to release stale schedules: %v", err)
+	}
+
+	due, err := s.queries.ListDueSchedules(ctx, now)
+	if err != nil {
+		log.Printf("Failed to list due schedules: %v", err)
+		return
+	}
+
+	for _, sched := range due {
+		// Claiming is atomic, so a schedule never runs twice at once, even
+		// with several server instances sharing the database.
+		claimed, err := s.queries.ClaimSchedule(ctx, db.ClaimScheduleParams{
+			ID:           sched.ID,
+			RunningSince: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			log.Printf("Failed to claim schedule %d: %v", sched.ID, err)
+			continue
+		}
+		if claimed == 0 {
+			continue
+		}
+		go s.execute(sched)
+	}
+}
+
+func (s *Service) execute(sched db.Schedule) {
+	log.Printf("Schedule %d: scanning %s", sched.ID, sched.StartUrl)
+
+	done := make(chan struct{})
+	go s.keepClaimed(sched.ID, done)
+
+	finish := db.FinishScheduleRunParams{ID: sched.ID}
+
+	opts, err := s.scanner.OpenOptions(sched.Options)
+	if err == nil {
+		opts.BypassCache = sched.BypassCache
+		var report *scanner.Report
+		report, err = s.scanner.Scan(sched.StartUrl, sched.UserID, opts)
+		if report != nil && report.ScanID != 0 {
+			finish.LastScanID = sql.NullInt32{Int32: report.ScanID, Valid: true}
+		}
+	}
+	if err != nil {
+		log.Printf("Schedule %d failed: %v", sched.ID, err)
+		finish.LastError = err.Error()
+	}
+
+	now := time.Now()
+	finish.LastRunAt = sql.NullTime{Time: now.UTC(), Valid: true}
+	finish.NextRunAt, err = nextRun(sched.CronExpr, sched.Timezone, now)
+	if err != nil {
+		// The expression was valid when saved; retry daily rather than spin.
+		log.Printf("Schedule %d: %v", sched.ID, err)
+		finish.NextRunAt = now.UTC().Add(24 * time.Hour)
+	}
+
+	close(done)
+	if err := s.queries.FinishScheduleRun(context.Background(), finish); err != nil {
+		log.Printf("Failed to record run of schedule %d: %v", sched.ID, err)
+	}
+}
+
+// keepClaimed refreshes the claim on a schedule until done is closed, so
+// runDue does not release it as stale while its scan is still running.
+func (s *Service) keepClaimed(id int32, done <-chan struct{}) {
+	ticker := time.NewTicker(claimRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := s.queries.RefreshScheduleClaim(context.Background(), db.RefreshScheduleClaimParams{
+				ID:           id,
+				RunningSince: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			})
+			if err != nil {
+				log.Printf("Failed to refresh claim of schedule %d: %v", id, err)
+			}
+		}
+	}
+}
+
+// nextRun returns the next run after t in UTC, matching the expression in
+// the schedule's timezone.
+func nextRun(expr, timezone string, t time.Time) (time.Time, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, timezone)
+	}
+
+	next := cron.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: %q never matches", ErrInvalidSchedule, expr)
+	}
+	return next.UTC(), nil
+}
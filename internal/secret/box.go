@@ -0,0 +1,57 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+var ErrNoKey = errors.New("encryption key is not configured")
+
+// Box encrypts small payloads with AES-256-GCM. The nonce is prepended to
+// the ciphertext.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox derives a 256-bit key from the passphrase. An empty passphrase
+// returns ErrNoKey.
+func NewBox(passphrase string) (*Box, error) {
+	if passphrase == "" {
+		return nil, ErrNoKey
+	}
+
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (b *Box) Open(ciphertext []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return b.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
@@ -0,0 +1,237 @@
+// Package siteconfig reads the .deadlink.yml file a site repository checks
+// in to describe how it is scanned.
+package siteconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-deadlink-scanner/internal/scanner"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is looked up in the working directory by the CLI.
+const DefaultFile = ".deadlink.yml"
+
+const currentVersion = 1
+
+// maxDepth caps scope.max_depth to keep runaway crawls in check.
+const maxDepth = 50
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Config is the content of a .deadlink.yml file:
+//
+//	version: 1
+//	start_urls:
+//	  - https://docs.example.com/
+//	scope:
+//	  hosts: [www.docs.example.com]
+//	  max_depth: 5
+//	include: ['^https://docs\.example\.com/']
+//	exclude: ['/api/reference/']
+//	ignore: ['^https://www\.linkedin\.com/']
+//	severity:
+//	  "403": warning
+//	  5xx: error
+//	  timeout: ignore
+//	auth:
+//	  headers:
+//	    Authorization: DOCS_AUTH_HEADER
+type Config struct {
+	Version   int               `yaml:"version"`
+	StartURLs []string          `yaml:"start_urls"`
+	Scope     Scope             `yaml:"scope"`
+	Include   []string          `yaml:"include"`
+	Exclude   []string          `yaml:"exclude"`
+	Ignore    []string          `yaml:"ignore"`
+	Severity  map[string]string `yaml:"severity"`
+	Auth      Auth              `yaml:"auth"`
+}
+
+type Scope struct {
+	// Hosts are crawled along with the start URLs' hosts.
+	Hosts    []string `yaml:"hosts"`
+	MaxDepth int      `yaml:"max_depth"`
+}
+
+// Auth names the environment variables holding secrets, so the file itself
+// can be committed.
+type Auth struct {
+	// Headers maps a request header to the variable holding its value.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// ValidationError lists every problem found in a config file.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid config: " + e.Problems[0]
+	}
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load reads and validates a config file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes and validates a config. Unknown keys and wrong types are
+// reported with their line numbers.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &ValidationError{Problems: []string{"file is empty"}}
+		}
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ValidationError{Problems: typeErr.Errors}
+		}
+		return nil, &ValidationError{Problems: []string{strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+
+	if problems := cfg.validate(); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Version {
+	case currentVersion:
+	case 0:
+		add("version: required, set it to %d", currentVersion)
+	default:
+		add("version: unsupported version %d, expected %d", c.Version, currentVersion)
+	}
+
+	for i, raw := range c.StartURLs {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("start_urls[%d]: %q is not an absolute http(s) URL", i, raw)
+		}
+	}
+
+	for i, host := range c.Scope.Hosts {
+		if host == "" || strings.ContainsAny(host, "/: ") {
+			add("scope.hosts[%d]: %q must be a bare host name such as www.example.com", i, host)
+		}
+	}
+	if c.Scope.MaxDepth < 0 || c.Scope.MaxDepth > maxDepth {
+		add("scope.max_depth: must be between 0 (default) and %d", maxDepth)
+	}
+
+	patternLists := []struct {
+		field    string
+		patterns []string
+	}{{"include", c.Include}, {"exclude", c.Exclude}, {"ignore", c.Ignore}}
+	for _, list := range patternLists {
+		for i, p := range list.patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				add("%s[%d]: %q is not a valid regular expression: %v", list.field, i, p, err)
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(c.Severity) {
+		if !scanner.ValidSeverityKey(strings.ToLower(key)) {
+			add("severity.%s: expected a 4xx/5xx status code, a status class like 4xx or a broken link category", key)
+		}
+		if !scanner.Severity(c.Severity[key]).Valid() {
+			add("severity.%s: %q must be error, warning or ignore", key, c.Severity[key])
+		}
+	}
+
+	for _, header := range sortedKeys(c.Auth.Headers) {
+		if strings.ContainsAny(header, ": \t") || header == "" {
+			add("auth.headers: %q is not a valid header name", header)
+		}
+		if name := c.Auth.Headers[header]; !envVarName.MatchString(name) {
+			add("auth.headers.%s: %q must be the name of an environment variable, not the secret itself", header, name)
+		}
+	}
+
+	return problems
+}
+
+// Rules returns the scan rules the config describes.
+func (c *Config) Rules() *scanner.ScanRules {
+	rules := &scanner.ScanRules{
+		Hosts:    c.Scope.Hosts,
+		MaxDepth: c.Scope.MaxDepth,
+		Include:  c.Include,
+		Exclude:  c.Exclude,
+		Ignore:   c.Ignore,
+	}
+	if len(c.Severity) > 0 {
+		rules.Severity = make(map[string]scanner.Severity, len(c.Severity))
+		for key, severity := range c.Severity {
+			rules.Severity[strings.ToLower(key)] = scanner.Severity(severity)
+		}
+	}
+	return rules
+}
+
+// Site is the host of the first start URL, which presets are stored under.
+func (c *Config) Site() string {
+	if len(c.StartURLs) == 0 {
+		return ""
+	}
+	return scanner.SiteOf(c.StartURLs[0])
+}
+
+// Headers resolves the auth headers from the environment. Every variable
+// must be set.
+func (c *Config) Headers(lookup func(string) (string, bool)) (map[string]string, error) {
+	headers := make(map[string]string, len(c.Auth.Headers))
+	var missing []string
+	for _, header := range sortedKeys(c.Auth.Headers) {
+		name := c.Auth.Headers[header]
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			missing = append(missing, fmt.Sprintf("%s (for %s)", name, header))
+			continue
+		}
+		headers[http.CanonicalHeaderKey(header)] = value
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return headers, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory is a Store that keeps scans for the life of the process, for the
+// CLI and for demos without a database. Results and link occurrences are
+// kept with their scan; those of unknown scans are dropped.
+type Memory struct {
+	mu       sync.RWMutex
+	maxScans int
+	// scans are oldest first.
+	scans            []*memoryScan
+	lastScanID       int32
+	lastResultID     int32
+	lastOccurrenceID int32
+}
+
+type memoryScan struct {
+	scan db.Scan
+	// results are in ID order.
+	results     []db.Result
+	occurrences []db.LinkOccurrence
+	seen        map[db.CreateLinkOccurrenceParams]bool
+}
+
+// NewMemory keeps at most maxScans scans, dropping the oldest with its
+// results when a new one is created. Zero keeps every scan.
+func NewMemory(maxScans int) *Memory {
+	return &Memory{maxScans: maxScans}
+}
+
+func (m *Memory) Persistent() bool {
+	return false
+}
+
+// find returns the scan with the given ID. The caller holds m.mu.
+func (m *Memory) find(id int32) *memoryScan {
+	for _, s := range m.scans {
+		if s.scan.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreateScan(ctx context.Context, arg db.CreateScanParams) (db.Scan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastScanID++
+	scan := db.Scan{
+		ID:          m.lastScanID,
+		UserID:      arg.UserID,
+		StartUrl:    arg.StartUrl,
+		Credentials: arg.Credentials,
+		StartedAt:   time.Now(),
+		Site:        arg.Site,
+	}
+	m.scans = append(m.scans, &memoryScan{scan: scan, seen: make(map[db.CreateLinkOccurrenceParams]bool)})
+	if m.maxScans > 0 && len(m.scans) > m.maxScans {
+		m.scans = slices.Delete(m.scans, 0, len(m.scans)-m.maxScans)
+	}
+	return scan, nil
+}
+
+func (m *Memory) FinishScan(ctx context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s := m.find(id); s != nil {
+		s.scan.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteScan(ctx context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.scans = slices.DeleteFunc(m.scans, func(s *memoryScan) bool { return s.scan.ID == id })
+	return nil
+}
+
+func (m *Memory) GetScan(ctx context.Context, id int32) (db.Scan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.find(id)
+	if s == nil {
+		return db.Scan{}, sql.ErrNoRows
+	}
+	return s.scan, nil
+}
+
+func (m *Memory) ListScans(ctx context.Context, userID, limit int32) ([]db.Scan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var scans []db.Scan
+	for i := len(m.scans) - 1; i >= 0 && len(scans) < int(limit); i-- {
+		if m.scans[i].scan.UserID == userID {
+			scans = append(scans, m.scans[i].scan)
+		}
+	}
+	return scans, nil
+}
+
+func (m *Memory) PreviousScan(ctx context.Context, arg db.GetPreviousScanParams) (db.Scan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := len(m.scans) - 1; i >= 0; i-- {
+		scan := m.scans[i].scan
+		if scan.UserID == arg.UserID && scan.Site == arg.Site && scan.StartedAt.Before(arg.StartedAt) && scan.FinishedAt.Valid {
+			return scan, nil
+		}
+	}
+	return db.Scan{}, sql.ErrNoRows
+}
+
+func (m *Memory) SaveResults(ctx context.Context, results []db.CreateResultParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, r := range results {
+		s := m.find(r.ScanID.Int32)
+		if !r.ScanID.Valid || s == nil {
+			continue
+		}
+		m.lastResultID++
+		s.results = append(s.results, db.Result{
+			ID:          m.lastResultID,
+			UserID:      r.UserID,
+			PageUrl:     r.PageUrl,
+			LinkUrl:     r.LinkUrl,
+			CheckedAt:   now,
+			Warning:     r.Warning,
+			Category:    r.Category,
+			StatusCode:  r.StatusCode,
+			ContentType: r.ContentType,
+			ErrorDetail: r.ErrorDetail,
+			DnsMs:       r.DnsMs,
+			ConnectMs:   r.ConnectMs,
+			TlsMs:       r.TlsMs,
+			TtfbMs:      r.TtfbMs,
+			TotalMs:     r.TotalMs,
+			ScanID:      r.ScanID,
+			RemoteIp:    r.RemoteIp,
+			Cached:      r.Cached,
+			Severity:    r.Severity,
+		})
+	}
+	return nil
+}
+
+func (m *Memory) UpdateResult(ctx context.Context, arg db.UpdateResultStatusParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.scans {
+		i := sort.Search(len(s.results), func(i int) bool { return s.results[i].ID >= arg.ID })
+		if i == len(s.results) || s.results[i].ID != arg.ID {
+			continue
+		}
+		r := &s.results[i]
+		r.Category = arg.Category
+		r.StatusCode = arg.StatusCode
+		r.ContentType = arg.ContentType
+		r.ErrorDetail = arg.ErrorDetail
+		r.Warning = arg.Warning
+		r.DnsMs = arg.DnsMs
+		r.ConnectMs = arg.ConnectMs
+		r.TlsMs = arg.TlsMs
+		r.TtfbMs = arg.TtfbMs
+		r.TotalMs = arg.TotalMs
+		r.RemoteIp = arg.RemoteIp
+		r.Cached = arg.Cached
+		r.CheckedAt = time.Now()
+		r.FixedAt = arg.FixedAt
+		r.Severity = arg.Severity
+		return nil
+	}
+	return nil
+}
+
+func (m *Memory) ListScanResults(ctx context.Context, scanID int32) ([]db.Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.find(scanID)
+	if s == nil {
+		return nil, nil
+	}
+	results := append([]db.Result(nil), s.results...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].LinkUrl < results[j].LinkUrl })
+	return results, nil
+}
+
+func (m *Memory) ListResults(ctx context.Context, arg db.ListResultsByUserFilteredParams) ([]db.Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []db.Result
+	for _, s := range m.scans {
+		for _, r := range s.results {
+			if r.UserID != arg.UserID || (arg.PageUrl != "" && r.PageUrl != arg.PageUrl) ||
+				(arg.Category != "" && r.Category != arg.Category) {
+				continue
+			}
+			results = append(results, r)
+		}
+	}
+
+	// Latest checks first, as in Postgres.
+	sort.SliceStable(results, func(i, j int) bool { return results[i].CheckedAt.After(results[j].CheckedAt) })
+	offset := min(int(max(arg.RowOffset, 0)), len(results))
+	end := min(offset+int(max(arg.RowLimit, 0)), len(results))
+	return results[offset:end], nil
+}
+
+func (m *Memory) SaveLinkOccurrences(ctx context.Context, occurrences []db.CreateLinkOccurrenceParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, o := range occurrences {
+		s := m.find(o.ScanID)
+		if s == nil || s.seen[o] {
+			continue
+		}
+		s.seen[o] = true
+		m.lastOccurrenceID++
+		s.occurrences = append(s.occurrences, db.LinkOccurrence{
+			ID:      m.lastOccurrenceID,
+			ScanID:  o.ScanID,
+			LinkUrl: o.LinkUrl,
+			PageUrl: o.PageUrl,
+		})
+	}
+	return nil
+}
+
+func (m *Memory) ListLinkOccurrences(ctx context.Context, scanID int32) ([]db.LinkOccurrence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.find(scanID)
+	if s == nil {
+		return nil, nil
+	}
+	occurrences := append([]db.LinkOccurrence(nil), s.occurrences...)
+	sort.Slice(occurrences, func(i, j int) bool {
+		if occurrences[i].LinkUrl != occurrences[j].LinkUrl {
+			return occurrences[i].LinkUrl < occurrences[j].LinkUrl
+		}
+		return occurrences[i].PageUrl < occurrences[j].PageUrl
+	})
+	return occurrences, nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+)
+
+// Postgres is the Store backed by the sqlc queries.
+type Postgres struct {
+	queries *db.Queries
+}
+
+func NewPostgres(queries *db.Queries) *Postgres {
+	return &Postgres{queries: queries}
+}
+
+func (p *Postgres) Persistent() bool {
+	return true
+}
+
+func (p *Postgres) CreateScan(ctx context.Context, arg db.CreateScanParams) (db.Scan, error) {
+	return p.queries.CreateScan(ctx, arg)
+}
+
+func (p *Postgres) FinishScan(ctx context.Context, id int32) error {
+	return p.queries.FinishScan(ctx, id)
+}
+
+func (p *Postgres) DeleteScan(ctx context.Context, id int32) error {
+	return p.queries.DeleteScan(ctx, id)
+}
+
+func (p *Postgres) GetScan(ctx context.Context, id int32) (db.Scan, error) {
+	return p.queries.GetScanByID(ctx, id)
+}
+
+func (p *Postgres) ListScans(ctx context.Context, userID, limit int32) ([]db.Scan, error) {
+	return p.queries.ListScansByUser(ctx, db.ListScansByUserParams{UserID: userID, Limit: limit})
+}
+
+func (p *Postgres) PreviousScan(ctx context.Context, arg db.GetPreviousScanParams) (db.Scan, error) {
+	return p.queries.GetPreviousScan(ctx, arg)
+}
+
+func (p *Postgres) SaveResults(ctx context.Context, results []db.CreateResultParams) error {
+	var errs []error
+	for _, r := range results {
+		if _, err := p.queries.CreateResult(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("save result for %s: %w", r.LinkUrl, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Postgres) UpdateResult(ctx context.Context, arg db.UpdateResultStatusParams) error {
+	return p.queries.UpdateResultStatus(ctx, arg)
+}
+
+func (p *Postgres) ListScanResults(ctx context.Context, scanID int32) ([]db.Result, error) {
+	return p.queries.ListResultsByScan(ctx, sql.NullInt32{Int32: scanID, Valid: true})
+}
+
+func (p *Postgres) ListResults(ctx context.Context, arg db.ListResultsByUserFilteredParams) ([]db.Result, error) {
+	return p.queries.ListResultsByUserFiltered(ctx, arg)
+}
+
+func (p *Postgres) SaveLinkOccurrences(ctx context.Context, occurrences []db.CreateLinkOccurrenceParams) error {
+	var errs []error
+	for _, o := range occurrences {
+		if err := p.queries.CreateLinkOccurrence(ctx, o); err != nil {
+			errs = append(errs, fmt.Errorf("save occurrence of %s on %s: %w", o.LinkUrl, o.PageUrl, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Postgres) ListLinkOccurrences(ctx context.Context, scanID int32) ([]db.LinkOccurrence, error) {
+	return p.queries.ListLinkOccurrencesByScan(ctx, scanID)
+}
@@ -0,0 +1,40 @@
+// Package store records scans, their results and the pages each link was
+// found on.
+package store
+
+import (
+	"context"
+	db "go-deadlink-scanner/internal/database/sqlc"
+)
+
+// Store keeps scans and their results. Lookups of a missing scan fail with
+// sql.ErrNoRows, whatever the implementation.
+type Store interface {
+	// Persistent reports whether scans outlive the process.
+	Persistent() bool
+
+	CreateScan(ctx context.Context, arg db.CreateScanParams) (db.Scan, error)
+	FinishScan(ctx context.Context, id int32) error
+	// DeleteScan removes a scan with its results.
+	DeleteScan(ctx context.Context, id int32) error
+	GetScan(ctx context.Context, id int32) (db.Scan, error)
+	// ListScans returns the user's latest scans, newest first.
+	ListScans(ctx context.Context, userID, limit int32) ([]db.Scan, error)
+	// PreviousScan returns the user's latest finished scan of a site that
+	// started before the given time.
+	PreviousScan(ctx context.Context, arg db.GetPreviousScanParams) (db.Scan, error)
+
+	// SaveResults stores every result it can and reports those it could
+	// not.
+	SaveResults(ctx context.Context, results []db.CreateResultParams) error
+	UpdateResult(ctx context.Context, arg db.UpdateResultStatusParams) error
+	// ListScanResults returns the results of a scan ordered by link.
+	ListScanResults(ctx context.Context, scanID int32) ([]db.Result, error)
+	// ListResults returns a user's results across scans, latest first.
+	ListResults(ctx context.Context, arg db.ListResultsByUserFilteredParams) ([]db.Result, error)
+
+	// SaveLinkOccurrences records the pages links were found on, ignoring
+	// occurrences already recorded.
+	SaveLinkOccurrences(ctx context.Context, occurrences []db.CreateLinkOccurrenceParams) error
+	ListLinkOccurrences(ctx context.Context, scanID int32) ([]db.LinkOccurrence, error)
+}
@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-deadlink-scanner/internal/scanner"
+	"strconv"
+	"strings"
+)
+
+// Formats of the request body. Generic posts the signed Payload as is; the
+// others post a message for the chat tool's incoming webhooks.
+const (
+	FormatGeneric    = "generic"
+	FormatSlack      = "slack"
+	FormatMattermost = "mattermost"
+	FormatTeams      = "teams"
+)
+
+var Formats = []string{FormatGeneric, FormatSlack, FormatMattermost, FormatTeams}
+
+func knownFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// slackHeaderLimit is the most characters Slack accepts in a header block.
+const slackHeaderLimit = 150
+
+// message is the tool-independent content of a chat notification.
+type message struct {
+	title     string
+	startURL  string
+	lines     []string
+	links     []scanner.SummaryLink
+	more      int
+	reportURL string
+}
+
+// encode renders p in the webhook's format.
+func encode(format string, p Payload, baseURL string) ([]byte, error) {
+	if format == "" || format == FormatGeneric {
+		return json.Marshal(p)
+	}
+
+	m := newMessage(p, baseURL)
+	switch format {
+	case FormatSlack:
+		return json.Marshal(slackMessage(m))
+	case FormatMattermost:
+		return json.Marshal(map[string]string{
+			"username": "Dead Link Scanner",
+			"text":     markdown(m, func(url, text string) string { return "[" + text + "](" + url + ")" }),
+		})
+	case FormatTeams:
+		return json.Marshal(teamsMessage(m))
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q", format)
+	}
+}
+
+func newMessage(p Payload, baseURL string) message {
+	if p.Event == EventPing {
+		return message{title: "Dead Link Scanner test message", lines: []string{"This channel will receive scan notifications."}}
+	}
+
+	s := p.Scan
+	m := message{startURL: s.StartURL}
+	if s.ScanID != 0 {
+		m.reportURL = baseURL + "/scans/compare?target=" + strconv.Itoa(int(s.ScanID))
+	}
+
+	switch scanner.EventType(p.Event) {
+	case scanner.EventScanFailed:
+		m.title = "Scan failed: " + s.StartURL
+		m.lines = []string{p.Error}
+	case scanner.EventNewlyBroken:
+		m.title = fmt.Sprintf("%d new broken links on %s", len(s.NewlyBroken), s.StartURL)
+		m.links = s.NewlyBroken
+	default:
+		m.title = fmt.Sprintf("Scan finished: %d of %d links broken on %s", s.Broken, s.Total, s.StartURL)
+		m.links = s.TopBroken
+		m.more = s.Broken - len(s.TopBroken)
+	}
+	if len(m.links) > 10 {
+		m.more += len(m.links) - 10
+		m.links = m.links[:10]
+	}
+	return m
+}
+
+func linkStatus(l scanner.SummaryLink) string {
+	if l.StatusCode > 0 {
+		return strconv.Itoa(l.StatusCode) + " " + string(l.Category)
+	}
+	return string(l.Category)
+}
+
+// markdown renders m as Markdown, with link formatting the tool's links.
+func markdown(m message, link func(url, text string) string) string {
+	var b strings.Builder
+	b.WriteString("**" + m.title + "**\n")
+	for _, line := range m.lines {
+		b.WriteString(line + "\n")
+	}
+	for _, l := range m.links {
+		fmt.Fprintf(&b, "- %s (%s)\n", l.URL, linkStatus(l))
+	}
+	if m.more > 0 {
+		fmt.Fprintf(&b, "- and %d more\n", m.more)
+	}
+	if m.reportURL != "" {
+		b.WriteString(link(m.reportURL, "Open the report") + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func slackMessage(m message) map[string]any {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+	// The header may be cut short, so the scanned URL is repeated in full.
+	var body strings.Builder
+	if m.startURL != "" {
+		fmt.Fprintf(&body, "<%s|%s>\n", escape(m.startURL), escape(m.startURL))
+	}
+	for _, line := range m.lines {
+		body.WriteString(escape(line) + "\n")
+	}
+	for _, l := range m.links {
+		fmt.Fprintf(&body, "• <%s|%s> (%s)\n", escape(l.URL), escape(l.URL), linkStatus(l))
+	}
+	if m.more > 0 {
+		fmt.Fprintf(&body, "• and %d more\n", m.more)
+	}
+	if m.reportURL != "" {
+		fmt.Fprintf(&body, "<%s|Open the report>", m.reportURL)
+	}
+
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": truncate(m.title, slackHeaderLimit)}},
+	}
+	if text := strings.TrimSpace(body.String()); text != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": text},
+		})
+	}
+
+	return map[string]any{"text": m.title, "blocks": blocks}
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis
+// when cut.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// teamsMessage builds an Adaptive Card as accepted by Teams workflow
+// webhooks.
+func teamsMessage(m message) map[string]any {
+	items := []map[string]any{
+		{"type": "TextBlock", "text": m.title, "weight": "Bolder", "size": "Medium", "wrap": true},
+	}
+	for _, line := range m.lines {
+		items = append(items, map[string]any{"type": "TextBlock", "text": line, "wrap": true})
+	}
+	for _, l := range m.links {
+		items = append(items, map[string]any{
+			"type": "TextBlock", "text": fmt.Sprintf("- [%s](%s) (%s)", l.URL, l.URL, linkStatus(l)), "wrap": true, "spacing": "None",
+		})
+	}
+	if m.more > 0 {
+		items = append(items, map[string]any{"type": "TextBlock", "text": fmt.Sprintf("and %d more", m.more), "spacing": "None"})
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    items,
+	}
+	if m.reportURL != "" {
+		card["actions"] = []map[string]any{
+			{"type": "Action.OpenUrl", "title": "Open the report", "url": m.reportURL},
+		}
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"database/sql"
+	"errors"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/secret"
+	webhookui "go-deadlink-scanner/internal/templates/webhook"
+	"go-deadlink-scanner/internal/ui"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// deliveryLogSize is the number of attempts listed per webhook.
+const deliveryLogSize = 20
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) WebhooksPage(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	rows, err := h.rows(c, userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Error loading webhooks: " + err.Error())
+	}
+
+	return ui.RenderComponent(c, webhookui.WebhooksPage(rows))
+}
+
+func (h *Handler) List(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+func (h *Handler) Create(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	var events []string
+	for _, raw := range c.Context().PostArgs().PeekMulti("events") {
+		for _, e := range strings.Split(string(raw), ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				events = append(events, e)
+			}
+		}
+	}
+
+	format := strings.TrimSpace(c.FormValue("format"))
+	site := strings.TrimSpace(c.FormValue("site"))
+	hook, signingSecret, err := h.service.Create(c.Context(), userId, strings.TrimSpace(c.FormValue("url")), events, format, site)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, ErrInvalidWebhook) || errors.Is(err, secret.ErrNoKey) {
+			status = fiber.StatusBadRequest
+		}
+		return h.fail(c, userId, status, err)
+	}
+
+	if !ui.IsHX(c) {
+		c.Status(fiber.StatusCreated)
+	}
+	return h.respondCreated(c, userId, nil, hook.ID, signingSecret)
+}
+
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid webhook id",
+		})
+	}
+
+	if err := h.service.Delete(c.Context(), userId, int32(id)); err != nil {
+		return h.fail(c, userId, fiber.StatusInternalServerError, err)
+	}
+
+	return h.respond(c, userId, nil)
+}
+
+// SendTest posts a ping to the webhook and returns the attempt, or the
+// refreshed list with its delivery log for htmx requests.
+func (h *Handler) SendTest(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid webhook id",
+		})
+	}
+
+	delivery, err := h.service.SendTest(c.Context(), userId, int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.fail(c, userId, fiber.StatusNotFound, errors.New("webhook not found"))
+		}
+		return h.fail(c, userId, fiber.StatusInternalServerError, err)
+	}
+
+	if ui.IsHX(c) {
+		return h.respond(c, userId, nil)
+	}
+	return c.JSON(deliveryJSON(delivery))
+}
+
+func (h *Handler) Deliveries(c *fiber.Ctx) error {
+	userId, ok := c.Locals("user_id").(int32)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id type",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid webhook id",
+		})
+	}
+
+	deliveries, err := h.service.Deliveries(c.Context(), userId, int32(id), int32(c.QueryInt("limit", 50)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "webhook not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load deliveries",
+		})
+	}
+
+	items := make([]fiber.Map, 0, len(deliveries))
+	for _, d := range deliveries {
+		items = append(items, deliveryJSON(d))
+	}
+	return c.JSON(fiber.Map{"deliveries": items})
+}
+
+// fail reports err as JSON, or re-renders the webhook list with the error
+// for htmx requests, which do not swap error responses.
+func (h *Handler) fail(c *fiber.Ctx, userID int32, status int, err error) error {
+	if ui.IsHX(c) {
+		return h.respond(c, userID, []string{err.Error()})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func (h *Handler) respond(c *fiber.Ctx, userID int32, errs []string) error {
+	return h.respondCreated(c, userID, errs, 0, "")
+}
+
+// respondCreated lists the webhooks with the signing secret of the one just
+// created, the only time the secret is shown.
+func (h *Handler) respondCreated(c *fiber.Ctx, userID int32, errs []string, createdID int32, signingSecret string) error {
+	if ui.IsHX(c) {
+		rows, err := h.rows(c, userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Error loading webhooks: " + err.Error())
+		}
+		for i := range rows {
+			if rows[i].ID == createdID {
+				rows[i].NewSecret = signingSecret
+			}
+		}
+		return ui.RenderComponent(c, webhookui.WebhookList(rows, errs))
+	}
+
+	hooks, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	items := make([]fiber.Map, 0, len(hooks))
+	for _, w := range hooks {
+		item := fiber.Map{
+			"id":         w.ID,
+			"url":        w.Url,
+			"events":     w.Events,
+			"format":     w.Format,
+			"site":       w.Site,
+			"created_at": w.CreatedAt,
+		}
+		if w.ID == createdID {
+			item["secret"] = signingSecret
+		}
+		items = append(items, item)
+	}
+	return c.JSON(fiber.Map{"webhooks": items})
+}
+
+func (h *Handler) rows(c *fiber.Ctx, userID int32) ([]webhookui.WebhookRow, error) {
+	hooks, err := h.service.List(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []webhookui.WebhookRow
+	for _, w := range hooks {
+		deliveries, err := h.service.Deliveries(c.Context(), userID, w.ID, deliveryLogSize)
+		if err != nil {
+			return nil, err
+		}
+
+		row := webhookui.WebhookRow{ID: w.ID, URL: w.Url, Events: w.Events, Format: w.Format, Site: w.Site}
+		for _, d := range deliveries {
+			result := d.Error
+			if d.StatusCode > 0 {
+				result = strconv.Itoa(int(d.StatusCode)) + " " + result
+			}
+			row.Deliveries = append(row.Deliveries, webhookui.DeliveryRow{
+				Event:   d.Event,
+				Attempt: d.Attempt,
+				Result:  strings.TrimSpace(result),
+				Success: d.Success,
+				At:      d.CreatedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func deliveryJSON(d db.WebhookDelivery) fiber.Map {
+	return fiber.Map{
+		"id":          d.ID,
+		"event":       d.Event,
+		"attempt":     d.Attempt,
+		"status_code": d.StatusCode,
+		"error":       d.Error,
+		"success":     d.Success,
+		"created_at":  d.CreatedAt,
+	}
+}
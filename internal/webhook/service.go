@@ -0,0 +1,335 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	db "go-deadlink-scanner/internal/database/sqlc"
+	"go-deadlink-scanner/internal/scanner"
+	"go-deadlink-scanner/internal/secret"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventPing is sent by the "send test" action only.
+const EventPing = "ping"
+
+// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the
+// request body, keyed with the webhook's secret.
+const SignatureHeader = "X-Deadlink-Signature"
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// retryDelays are the waits before each delivery attempt. Retries are held
+// in memory and are lost when the server restarts.
+var retryDelays = []time.Duration{0, 30 * time.Second, 2 * time.Minute, 10 * time.Minute, 30 * time.Minute}
+
+type Service struct {
+	queries *db.Queries
+	box     *secret.Box
+	client  *http.Client
+	delays  []time.Duration
+	baseURL string
+}
+
+// Payload is the JSON body posted to generic webhooks. Chat formats are
+// rendered from it, see encode.
+type Payload struct {
+	Event  string           `json:"event"`
+	SentAt time.Time        `json:"sent_at"`
+	Scan   *scanner.Summary `json:"scan,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// NewService creates the webhook service. Signing secrets are sealed with
+// box, and webhooks cannot be created without one. baseURL is used for report
+// links in chat messages.
+func NewService(queries *db.Queries, box *secret.Box, baseURL string) *Service {
+	return &Service{
+		queries: queries,
+		box:     box,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		delays:  retryDelays,
+		baseURL: baseURL,
+	}
+}
+
+// Create registers a webhook and returns it with its signing secret, which
+// is stored sealed and never returned again. format is one of Formats and
+// defaults to generic. site limits the webhook to scans of one host; it may
+// be given as a host or a URL, and empty means all sites.
+func (s *Service) Create(ctx context.Context, userID int32, rawURL string, events []string, format, site string) (db.Webhook, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return db.Webhook{}, "", fmt.Errorf("%w: URL must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+
+	if len(events) == 0 {
+		for _, e := range scanner.EventTypes {
+			events = append(events, string(e))
+		}
+	}
+	for _, e := range events {
+		if !knownEvent(e) {
+			return db.Webhook{}, "", fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, e)
+		}
+	}
+
+	if format == "" {
+		format = FormatGeneric
+	}
+	if !knownFormat(format) {
+		return db.Webhook{}, "", fmt.Errorf("%w: unknown format %q", ErrInvalidWebhook, format)
+	}
+
+	site, err = normalizeSite(site)
+	if err != nil {
+		return db.Webhook{}, "", err
+	}
+
+	if s.box == nil {
+		return db.Webhook{}, "", fmt.Errorf("cannot sign webhooks: %w", secret.ErrNoKey)
+	}
+	signingSecret, err := newSecret()
+	if err != nil {
+		return db.Webhook{}, "", err
+	}
+	sealed, err := s.box.Seal([]byte(signingSecret))
+	if err != nil {
+		return db.Webhook{}, "", fmt.Errorf("encrypt webhook secret: %w", err)
+	}
+
+	hook, err := s.queries.CreateWebhook(ctx, db.CreateWebhookParams{
+		UserID:       userID,
+		Url:          rawURL,
+		SealedSecret: sealed,
+		Events:       events,
+		Format:       format,
+		Site:         site,
+	})
+	if err != nil {
+		return db.Webhook{}, "", err
+	}
+	return hook, signingSecret, nil
+}
+
+// SealSecrets seals the plaintext secrets of webhooks created before secrets
+// were sealed. It is a no-op without a key.
+func (s *Service) SealSecrets(ctx context.Context) {
+	if s.box == nil {
+		return
+	}
+
+	hooks, err := s.queries.ListUnsealedWebhooks(ctx)
+	if err != nil {
+		log.Printf("Failed to list webhooks with plaintext secrets: %v", err)
+		return
+	}
+	for _, hook := range hooks {
+		sealed, err := s.box.Seal([]byte(hook.Secret))
+		if err != nil {
+			log.Printf("Failed to seal secret of webhook %d: %v", hook.ID, err)
+			continue
+		}
+		if err := s.queries.SealWebhookSecret(ctx, db.SealWebhookSecretParams{ID: hook.ID, SealedSecret: sealed}); err != nil {
+			log.Printf("Failed to seal secret of webhook %d: %v", hook.ID, err)
+		}
+	}
+}
+
+// signingSecret opens the webhook's sealed secret. Webhooks not sealed yet
+// still carry it in plaintext.
+func (s *Service) signingSecret(hook db.Webhook) (string, error) {
+	if len(hook.SealedSecret) == 0 {
+		return hook.Secret, nil
+	}
+	if s.box == nil {
+		return "", secret.ErrNoKey
+	}
+	plain, err := s.box.Open(hook.SealedSecret)
+	if err != nil {
+		return "", fmt.Errorf("decrypt webhook secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+func (s *Service) List(ctx context.Context, userID int32) ([]db.Webhook, error) {
+	return s.queries.ListWebhooksByUser(ctx, userID)
+}
+
+func (s *Service) Delete(ctx context.Context, userID, id int32) error {
+	return s.queries.DeleteWebhook(ctx, db.DeleteWebhookParams{ID: id, UserID: userID})
+}
+
+// Deliveries returns the latest delivery attempts of one of the user's
+// webhooks, newest first.
+func (s *Service) Deliveries(ctx context.Context, userID, id int32, limit int32) ([]db.WebhookDelivery, error) {
+	if _, err := s.queries.GetWebhookByID(ctx, db.GetWebhookByIDParams{ID: id, UserID: userID}); err != nil {
+		return nil, err
+	}
+	return s.queries.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: id, Limit: limit})
+}
+
+// SendTest posts a ping once, without retries, and returns the attempt.
+func (s *Service) SendTest(ctx context.Context, userID, id int32) (db.WebhookDelivery, error) {
+	hook, err := s.queries.GetWebhookByID(ctx, db.GetWebhookByIDParams{ID: id, UserID: userID})
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	body, err := encode(hook.Format, Payload{Event: EventPing, SentAt: time.Now()}, s.baseURL)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	delivery, _ := s.attempt(ctx, hook, EventPing, body, 1)
+	return delivery, nil
+}
+
+// HandleEvent delivers a scan event to the user's webhooks that subscribed
+// to it and cover the scanned site. It is registered as a scanner listener.
+func (s *Service) HandleEvent(e scanner.Event) {
+	ctx := context.Background()
+
+	hooks, err := s.queries.ListWebhooksForEvent(ctx, db.ListWebhooksForEventParams{
+		UserID: e.UserID,
+		Event:  string(e.Type),
+	})
+	if err != nil {
+		log.Printf("Failed to load webhooks for user %d: %v", e.UserID, err)
+		return
+	}
+
+	payload := Payload{Event: string(e.Type), SentAt: e.At, Scan: e.Summary, Error: e.Error}
+	site := scanner.SiteOf(e.Summary.StartURL)
+
+	for _, hook := range hooks {
+		if hook.Site != "" && hook.Site != site {
+			continue
+		}
+		body, err := encode(hook.Format, payload, s.baseURL)
+		if err != nil {
+			log.Printf("Failed to encode payload for webhook %d: %v", hook.ID, err)
+			continue
+		}
+		go s.deliver(hook, string(e.Type), body)
+	}
+}
+
+func (s *Service) deliver(hook db.Webhook, event string, body []byte) {
+	for i, delay := range s.delays {
+		time.Sleep(delay)
+		if _, retry := s.attempt(context.Background(), hook, event, body, i+1); !retry {
+			return
+		}
+	}
+	log.Printf("Webhook %d: giving up on %s after %d attempts", hook.ID, event, len(s.delays))
+}
+
+// attempt posts body once and records the outcome. It reports whether a
+// failed attempt is worth retrying.
+func (s *Service) attempt(ctx context.Context, hook db.Webhook, event string, body []byte, n int) (db.WebhookDelivery, bool) {
+	params := db.CreateWebhookDeliveryParams{
+		WebhookID: hook.ID,
+		Event:     event,
+		Payload:   string(body),
+		Attempt:   int32(n),
+	}
+
+	retry := false
+	resp, err := s.post(ctx, hook, event, body, n)
+	if err != nil {
+		params.Error = err.Error()
+		retry = true
+	} else {
+		params.StatusCode = int32(resp.StatusCode)
+		params.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+		if !params.Success {
+			params.Error = http.StatusText(resp.StatusCode)
+			retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+				resp.StatusCode == http.StatusRequestTimeout
+		}
+	}
+
+	delivery, err := s.queries.CreateWebhookDelivery(ctx, params)
+	if err != nil {
+		log.Printf("Failed to record delivery for webhook %d: %v", hook.ID, err)
+	}
+	return delivery, retry
+}
+
+func (s *Service) post(ctx context.Context, hook db.Webhook, event string, body []byte, n int) (*http.Response, error) {
+	signingSecret, err := s.signingSecret(hook)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DeadLinkChecker-Webhook/1.0")
+	req.Header.Set("X-Deadlink-Event", event)
+	req.Header.Set("X-Deadlink-Attempt", strconv.Itoa(n))
+	req.Header.Set(SignatureHeader, Sign(signingSecret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return resp, nil
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func knownEvent(event string) bool {
+	for _, e := range scanner.EventTypes {
+		if string(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSite reduces a host or URL to the lowercased host that
+// scanner.SiteOf reports for scans.
+func normalizeSite(site string) (string, error) {
+	site = strings.TrimSpace(site)
+	if site == "" {
+		return "", nil
+	}
+	if !strings.Contains(site, "://") {
+		site = "http://" + site
+	}
+	host := scanner.SiteOf(site)
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return "", fmt.Errorf("%w: site must be a host name or URL", ErrInvalidWebhook)
+	}
+	return host, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
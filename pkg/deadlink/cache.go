@@ -0,0 +1,86 @@
+package deadlink
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Cache shares the results of external links between crawls. Keys are
+// normalized with NormalizeURL.
+type Cache interface {
+	// Get returns a fresh result for the link, if there is one.
+	Get(ctx context.Context, key string) (*Result, bool)
+	Put(ctx context.Context, key string, result *Result)
+}
+
+// Page is what a PageStore keeps of a crawled page: its validators and the
+// links found on it.
+type Page struct {
+	ETag         string
+	LastModified string
+	Links        []string
+}
+
+// PageStore lets crawls skip re-parsing pages that have not changed. A page
+// answering 304 Not Modified keeps the links stored for it.
+type PageStore interface {
+	// LoadPage returns the page stored by an earlier crawl, or nil.
+	LoadPage(ctx context.Context, pageURL string) *Page
+	SavePage(ctx context.Context, pageURL string, page Page)
+}
+
+// setConditionalHeaders turns the GET into a conditional request based on the
+// validators the server sent last time.
+func setConditionalHeaders(req *http.Request, prev *Page) {
+	if prev == nil {
+		return
+	}
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+}
+
+// rememberPage stores the page's validators and links for the next crawl.
+// Pages without validators are skipped: they can never answer 304.
+func (c *Crawler) rememberPage(ctx context.Context, pageURL string, resp *http.Response, links []string) {
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if c.opts.Pages == nil || (etag == "" && lastModified == "") {
+		return
+	}
+	c.opts.Pages.SavePage(ctx, pageURL, Page{ETag: etag, LastModified: lastModified, Links: links})
+}
+
+// NormalizeURL builds the cache key: scheme and host are lowercased, default
+// ports and fragments dropped and an empty path becomes "/".
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String()
+}
@@ -0,0 +1,117 @@
+package deadlink
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Category is the typed outcome of checking a single link.
+type Category string
+
+const (
+	CategoryOK                Category = "ok"
+	CategoryRedirect          Category = "redirect"
+	CategoryClientError       Category = "client_error"
+	CategoryServerError       Category = "server_error"
+	CategoryTimeout           Category = "timeout"
+	CategoryDNSFailure        Category = "dns_failure"
+	CategoryConnectionRefused Category = "connection_refused"
+	// CategoryConnectionReset is a connection the server dropped before
+	// sending a complete response.
+	CategoryConnectionReset Category = "connection_reset"
+	// CategoryNetworkError is any other failure to get a response.
+	CategoryNetworkError     Category = "network_error"
+	CategoryTLSError         Category = "tls_error"
+	CategoryTooManyRedirects Category = "too_many_redirects"
+	CategoryInvalidURL       Category = "invalid_url"
+	CategorySkipped          Category = "skipped"
+)
+
+var Categories = []Category{
+	CategoryOK,
+	CategoryRedirect,
+	CategoryClientError,
+	CategoryServerError,
+	CategoryTimeout,
+	CategoryDNSFailure,
+	CategoryConnectionRefused,
+	CategoryConnectionReset,
+	CategoryNetworkError,
+	CategoryTLSError,
+	CategoryTooManyRedirects,
+	CategoryInvalidURL,
+	CategorySkipped,
+}
+
+// ErrTooManyRedirects is returned by the default client's redirect policy
+// and reported as CategoryTooManyRedirects.
+var ErrTooManyRedirects = fmt.Errorf("stopped after %d redirects", MaxRedirects)
+
+func ParseCategory(s string) (Category, bool) {
+	for _, c := range Categories {
+		if string(c) == s {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// IsBroken reports whether links in this category should be treated as dead.
+func (c Category) IsBroken() bool {
+	switch c {
+	case CategoryOK, CategoryRedirect, CategorySkipped:
+		return false
+	default:
+		return true
+	}
+}
+
+// CategoryForStatus maps an HTTP status code to a category.
+func CategoryForStatus(code int) Category {
+	switch {
+	case code >= 500:
+		return CategoryServerError
+	case code >= 400:
+		return CategoryClientError
+	case code >= 300:
+		return CategoryRedirect
+	default:
+		return CategoryOK
+	}
+}
+
+// CategoryForError maps a transport-level error from http.Client.Do to a
+// category.
+func CategoryForError(err error) Category {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, ErrTooManyRedirects):
+		return CategoryTooManyRedirects
+	case errors.As(err, &dnsErr):
+		return CategoryDNSFailure
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return CategoryTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return CategoryConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return CategoryConnectionReset
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &alertErr),
+		errors.As(err, &unknownAuthErr), errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return CategoryTLSError
+	default:
+		return CategoryNetworkError
+	}
+}
@@ -0,0 +1,506 @@
+// Package deadlink crawls a site and checks every link it finds. It has no
+// database or web framework dependencies: results are returned, or passed
+// to callbacks as they come in, and caching is left to the caller.
+//
+//	crawler, err := deadlink.New("https://example.com", deadlink.Options{Workers: 10})
+//	if err != nil {
+//		return err
+//	}
+//	results, err := crawler.Crawl(ctx)
+//	for _, r := range results {
+//		if r.Category.IsBroken() {
+//			fmt.Println(r.URL, r.StatusCode, r.Error)
+//		}
+//	}
+package deadlink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultWorkers  = 10
+	DefaultMaxDepth = 10
+	DefaultTimeout  = 30 * time.Second
+	// MaxRedirects is how many redirects the default client follows.
+	MaxRedirects     = 5
+	DefaultUserAgent = "Mozilla/5.0 (compatible; DeadLinkChecker/1.0)"
+)
+
+// queueSize bounds the links waiting to be checked. Links found while the
+// queue is full are dropped.
+const queueSize = 1000
+
+// Result is the outcome of checking a single link.
+type Result struct {
+	URL         string
+	Category    Category
+	StatusCode  int
+	ContentType string
+	Error       string
+	Warning     string
+	Timing      Timing
+	RemoteIP    string
+	// Cached is set when the result was served from Options.Cache.
+	Cached bool
+}
+
+// Options configure a Crawler. The zero value crawls with the defaults.
+type Options struct {
+	// Client sends every request. It defaults to NewClient(nil).
+	Client *http.Client
+	// Do sends a request in place of Client.Do, e.g. to add credentials.
+	Do func(*http.Request) (*http.Response, error)
+	// Workers is how many links are checked at once.
+	Workers int
+	// MaxDepth limits how many links deep pages are followed.
+	MaxDepth int
+	// Timeout bounds a whole crawl.
+	Timeout   time.Duration
+	UserAgent string
+	// Hosts are crawled like the start URL's host, e.g. "www.example.com"
+	// next to "example.com". Links to other hosts are checked only.
+	Hosts []string
+
+	// CertWarnDays warns about certificates expiring within this many days.
+	CertWarnDays int
+	// SlowThreshold and SlowTTFB warn about links whose response or first
+	// byte took longer. Zero disables the warning.
+	SlowThreshold time.Duration
+	SlowTTFB      time.Duration
+
+	// Skip returns why a link must not be requested, or "" when it may.
+	// Skipped links are reported with CategorySkipped.
+	Skip func(link string) string
+	// Cache, when set, serves results of links to other hosts.
+	Cache Cache
+	// Pages, when set, makes page fetches conditional on the validators
+	// stored by the last crawl.
+	Pages PageStore
+
+	// OnResult is called for every checked link as the crawl goes. Calls
+	// are not concurrent.
+	OnResult func(*Result)
+	// OnLinks is called with the links found on every page crawled. It may
+	// be called concurrently.
+	OnLinks func(page string, links []string)
+	// Logf receives progress messages. By default nothing is logged.
+	Logf func(format string, args ...any)
+}
+
+// Crawler checks the links of one site.
+type Crawler struct {
+	opts  Options
+	start string
+	base  *url.URL
+	hosts map[string]bool
+
+	certs      map[string]*certEntry
+	certsMutex sync.Mutex
+}
+
+// NewClient returns the client crawlers use by default: requests time out
+// after 5 seconds and at most MaxRedirects redirects are followed. A nil
+// transport uses http.DefaultTransport.
+func NewClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		Timeout:   5 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			return nil
+		},
+	}
+}
+
+// New returns a crawler for the site at startURL. Links to its host are
+// internal: they are crawled and never served from the cache.
+func New(startURL string, opts Options) (*Crawler, error) {
+	base, err := url.Parse(startURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+
+	if opts.Client == nil {
+		opts.Client = NewClient(nil)
+	}
+	if opts.Do == nil {
+		opts.Do = opts.Client.Do
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	c := &Crawler{
+		opts:  opts,
+		start: startURL,
+		base:  base,
+		hosts: make(map[string]bool, len(opts.Hosts)),
+		certs: make(map[string]*certEntry),
+	}
+	for _, host := range opts.Hosts {
+		c.hosts[strings.ToLower(strings.TrimSpace(host))] = true
+	}
+	return c, nil
+}
+
+// Crawl checks the start URL and follows the links of every HTML page on
+// the site, up to Options.MaxDepth deep. When Options.Timeout passes, the
+// links checked so far are returned.
+func Crawl(ctx context.Context, startURL string, opts Options) ([]*Result, error) {
+	c, err := New(startURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.Crawl(ctx)
+}
+
+type linkJob struct {
+	url   string
+	depth int
+}
+
+type crawl struct {
+	jobs    chan linkJob
+	pending sync.WaitGroup
+
+	visited      map[string]bool
+	visitedMutex sync.Mutex
+
+	results      []*Result
+	resultsMutex sync.Mutex
+}
+
+// Crawl is the package level Crawl for the crawler's start URL.
+func (c *Crawler) Crawl(ctx context.Context) ([]*Result, error) {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	cr := &crawl{
+		jobs:    make(chan linkJob, queueSize),
+		visited: make(map[string]bool),
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.opts.Workers; i++ {
+		workers.Add(1)
+		go func(id int) {
+			defer workers.Done()
+			for job := range cr.jobs {
+				c.visit(ctx, id, job, cr)
+				cr.pending.Done()
+			}
+		}(i)
+	}
+
+	cr.pending.Add(1)
+	cr.jobs <- linkJob{url: c.start}
+	cr.pending.Wait()
+	close(cr.jobs)
+	workers.Wait()
+
+	if err := parent.Err(); err != nil {
+		return cr.results, err
+	}
+	if ctx.Err() != nil {
+		c.logf("Crawl timed out after %s. Found %d links so far", c.opts.Timeout, len(cr.results))
+	} else {
+		c.logf("Crawl completed. Found %d links", len(cr.results))
+	}
+	return cr.results, nil
+}
+
+func (c *Crawler) visit(ctx context.Context, id int, job linkJob, cr *crawl) {
+	// Once the crawl is over, the queue is only drained.
+	if ctx.Err() != nil {
+		return
+	}
+
+	cr.visitedMutex.Lock()
+	if cr.visited[job.url] {
+		cr.visitedMutex.Unlock()
+		return
+	}
+	cr.visited[job.url] = true
+	cr.visitedMutex.Unlock()
+
+	c.logf("Worker %d: checking %s (depth: %d)", id, job.url, job.depth)
+
+	result := c.Check(ctx, job.url)
+	if ctx.Err() != nil {
+		// The request was cut short by the end of the crawl.
+		return
+	}
+
+	cr.resultsMutex.Lock()
+	cr.results = append(cr.results, result)
+	if c.opts.OnResult != nil {
+		c.opts.OnResult(result)
+	}
+	cr.resultsMutex.Unlock()
+
+	if result.StatusCode != 200 || job.depth >= c.opts.MaxDepth || !strings.Contains(result.ContentType, "text/html") ||
+		!c.Crawlable(job.url) {
+		return
+	}
+
+	links := c.Links(ctx, job.url)
+	if c.opts.OnLinks != nil {
+		c.opts.OnLinks(job.url, links)
+	}
+	for _, link := range links {
+		cr.visitedMutex.Lock()
+		if !cr.visited[link] {
+			cr.pending.Add(1)
+			select {
+			case cr.jobs <- linkJob{url: link, depth: job.depth + 1}:
+			default:
+				// Channel is full, skip this link
+				cr.pending.Done()
+			}
+		}
+		cr.visitedMutex.Unlock()
+	}
+}
+
+// Crawlable reports whether the page's links should be followed: it is on
+// the start URL's host or on one of Options.Hosts.
+func (c *Crawler) Crawlable(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return u.Host == c.base.Host || c.hosts[strings.ToLower(u.Hostname())]
+}
+
+func (c *Crawler) internal(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return u.Host == c.base.Host
+}
+
+// Check requests a single link without following it. Links to other hosts
+// are served from Options.Cache when one is set.
+func (c *Crawler) Check(ctx context.Context, link string) *Result {
+	// Skipped links are reported as such even when another scan cached them.
+	if result := c.precheck(link); result != nil {
+		return result
+	}
+	if c.opts.Cache == nil || c.internal(link) {
+		return c.fetch(ctx, link)
+	}
+
+	key := NormalizeURL(link)
+	if cached, ok := c.opts.Cache.Get(ctx, key); ok {
+		cached.URL = link
+		cached.Cached = true
+		return cached
+	}
+
+	result := c.fetch(ctx, link)
+	if result.Category != CategoryInvalidURL && ctx.Err() == nil {
+		c.opts.Cache.Put(ctx, key, result)
+	}
+	return result
+}
+
+// precheck returns the result of a link that is not to be requested: an
+// invalid URL, an unsupported scheme or a link Options.Skip leaves out. It
+// returns nil for links to fetch.
+func (c *Crawler) precheck(link string) *Result {
+	u, err := url.Parse(link)
+	switch {
+	case err != nil:
+		return &Result{URL: link, Category: CategoryInvalidURL, Error: err.Error()}
+	case u.Scheme != "http" && u.Scheme != "https":
+		return &Result{URL: link, Category: CategorySkipped, Error: fmt.Sprintf("unsupported scheme %q", u.Scheme)}
+	case u.Host == "":
+		return &Result{URL: link, Category: CategoryInvalidURL, Error: "missing host"}
+	}
+	if c.opts.Skip != nil {
+		if reason := c.opts.Skip(link); reason != "" {
+			return &Result{URL: link, Category: CategorySkipped, Error: reason}
+		}
+	}
+	return nil
+}
+
+func (c *Crawler) fetch(ctx context.Context, link string) *Result {
+	result := &Result{URL: link}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", link, nil)
+	if err != nil {
+		result.Category = CategoryInvalidURL
+		result.Error = err.Error()
+		return result
+	}
+
+	cert := c.checkCertificate(req.URL)
+	if cert != nil && cert.IsError() {
+		result.Category = CategoryTLSError
+		result.Error = fmt.Sprintf("%s: %s", cert.Problem, cert.Detail)
+		return result
+	}
+	if cert != nil && cert.Problem == CertExpiringSoon {
+		result.Warning = JoinWarnings(result.Warning, cert.Detail)
+	}
+
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+
+	req, traceDone := traceRequest(req, result)
+	resp, err := c.opts.Do(req)
+	traceDone()
+	if err != nil {
+		result.Category = CategoryForError(err)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	if warning := slowWarning(result.Timing, c.opts.SlowThreshold, c.opts.SlowTTFB); warning != "" {
+		result.Warning = JoinWarnings(result.Warning, warning)
+	}
+
+	result.StatusCode = resp.StatusCode
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.Category = CategoryForStatus(resp.StatusCode)
+	if result.Category.IsBroken() {
+		result.Error = http.StatusText(resp.StatusCode)
+	}
+
+	return result
+}
+
+// Links fetches a page and returns the unique links on it, resolved against
+// the start URL. It returns nil when the page cannot be read.
+func (c *Crawler) Links(ctx context.Context, pageURL string) []string {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		c.logf("Failed to build request for %s: %v", pageURL, err)
+		return nil
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+
+	var prev *Page
+	if c.opts.Pages != nil {
+		prev = c.opts.Pages.LoadPage(ctx, pageURL)
+	}
+	setConditionalHeaders(req, prev)
+
+	resp, err := c.opts.Do(req)
+	if err != nil {
+		c.logf("Failed to get page %s: %v", pageURL, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && prev != nil {
+		c.logf("Page %s not modified, reusing %d stored links", pageURL, len(prev.Links))
+		return prev.Links
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		c.logf("Failed to read body from %s: %v", pageURL, err)
+		return nil
+	}
+
+	doc, err := html.Parse(strings.NewReader(string(body)))
+	if err != nil {
+		c.logf("Failed to parse HTML from %s: %v", pageURL, err)
+		return nil
+	}
+
+	var links []string
+	c.traverseHTML(doc, &links)
+
+	linkMap := make(map[string]bool)
+	var uniqueLinks []string
+	for _, link := range links {
+		if !linkMap[link] {
+			linkMap[link] = true
+			uniqueLinks = append(uniqueLinks, link)
+		}
+	}
+
+	c.rememberPage(ctx, pageURL, resp, uniqueLinks)
+
+	return uniqueLinks
+}
+
+func (c *Crawler) traverseHTML(n *html.Node, links *[]string) {
+	if n.Type == html.ElementNode && n.Data == "a" {
+		for _, attr := range n.Attr {
+			if attr.Key == "href" {
+				link := c.resolveURL(attr.Val)
+				if link != "" {
+					*links = append(*links, link)
+				}
+				break
+			}
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.traverseHTML(child, links)
+	}
+}
+
+func (c *Crawler) resolveURL(href string) string {
+	if href == "" || strings.HasPrefix(href, "#") ||
+		strings.HasPrefix(href, "mailto:") || strings.HasPrefix(href, "tel:") {
+		return ""
+	}
+
+	linkURL, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	resolvedURL := c.base.ResolveReference(linkURL)
+	return resolvedURL.String()
+}
+
+func (c *Crawler) transport() http.RoundTripper {
+	if c.opts.Client.Transport != nil {
+		return c.opts.Client.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (c *Crawler) logf(format string, args ...any) {
+	if c.opts.Logf != nil {
+		c.opts.Logf(format, args...)
+	}
+}
+
+// JoinWarnings appends warning to the existing warnings of a result.
+func JoinWarnings(existing, warning string) string {
+	if existing == "" {
+		return warning
+	}
+	return existing + "; " + warning
+}
@@ -0,0 +1,113 @@
+package deadlink
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing breaks a link check down into its network phases. When a request
+// follows redirects the phases of every hop are summed.
+type Timing struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration
+	Total   time.Duration
+}
+
+type timingTrace struct {
+	mu       sync.Mutex
+	timing   *Timing
+	remoteIP *string
+	start    time.Time
+	dnsStart time.Time
+	conStart time.Time
+	tlsStart time.Time
+	reqStart time.Time
+}
+
+// traceRequest attaches an httptrace.ClientTrace to req that records the
+// timing and the IP of the first connection into result. The returned func
+// must be called once the response has been received.
+func traceRequest(req *http.Request, result *Result) (*http.Request, func()) {
+	tr := &timingTrace{timing: &result.Timing, remoteIP: &result.RemoteIP, start: time.Now()}
+	tr.reqStart = tr.start
+
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			tr.mu.Lock()
+			tr.reqStart = time.Now()
+			tr.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tr.mu.Lock()
+			if *tr.remoteIP == "" && info.Conn != nil {
+				if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+					*tr.remoteIP = host
+				}
+			}
+			tr.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tr.mu.Lock()
+			tr.dnsStart = time.Now()
+			tr.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tr.mu.Lock()
+			tr.timing.DNS += time.Since(tr.dnsStart)
+			tr.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			tr.mu.Lock()
+			tr.conStart = time.Now()
+			tr.mu.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			tr.mu.Lock()
+			tr.timing.Connect += time.Since(tr.conStart)
+			tr.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			tr.mu.Lock()
+			tr.tlsStart = time.Now()
+			tr.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tr.mu.Lock()
+			tr.timing.TLS += time.Since(tr.tlsStart)
+			tr.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			tr.mu.Lock()
+			tr.timing.TTFB += time.Since(tr.reqStart)
+			tr.mu.Unlock()
+		},
+	}
+
+	done := func() {
+		tr.mu.Lock()
+		tr.timing.Total = time.Since(tr.start)
+		tr.mu.Unlock()
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), done
+}
+
+// slowWarning returns a warning when the timing exceeds the configured
+// thresholds. A zero threshold disables that check.
+func slowWarning(t Timing, totalThreshold, ttfbThreshold time.Duration) string {
+	switch {
+	case totalThreshold > 0 && t.Total > totalThreshold:
+		return fmt.Sprintf("slow response: %d ms (threshold %d ms)", t.Total.Milliseconds(), totalThreshold.Milliseconds())
+	case ttfbThreshold > 0 && t.TTFB > ttfbThreshold:
+		return fmt.Sprintf("slow first byte: %d ms (threshold %d ms)", t.TTFB.Milliseconds(), ttfbThreshold.Milliseconds())
+	default:
+		return ""
+	}
+}
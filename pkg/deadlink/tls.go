@@ -0,0 +1,167 @@
+package deadlink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type CertProblem string
+
+const (
+	CertExpired          CertProblem = "certificate expired"
+	CertNotYetValid      CertProblem = "certificate not yet valid"
+	CertSelfSigned       CertProblem = "self-signed certificate"
+	CertUnknownAuthority CertProblem = "certificate signed by unknown authority"
+	CertHostnameMismatch CertProblem = "certificate hostname mismatch"
+	CertInvalid          CertProblem = "invalid certificate"
+	CertExpiringSoon     CertProblem = "certificate expiring soon"
+)
+
+// CertStatus is the outcome of a TLS handshake against a single host.
+// Problem is empty when the certificate is valid and not close to expiry.
+type CertStatus struct {
+	Host     string
+	Problem  CertProblem
+	NotAfter time.Time
+	Detail   string
+}
+
+// IsError reports whether the certificate is unusable, as opposed to merely
+// expiring soon.
+func (c *CertStatus) IsError() bool {
+	return c.Problem != "" && c.Problem != CertExpiringSoon
+}
+
+type certEntry struct {
+	once   sync.Once
+	status *CertStatus
+}
+
+// checkCertificate inspects the certificate of the link's host once per
+// crawler. It returns nil for non-HTTPS links and for hosts that could not
+// be reached.
+func (c *Crawler) checkCertificate(linkURL *url.URL) *CertStatus {
+	if linkURL.Scheme != "https" || linkURL.Hostname() == "" {
+		return nil
+	}
+
+	port := linkURL.Port()
+	if port == "" {
+		port = "443"
+	}
+	addr := net.JoinHostPort(linkURL.Hostname(), port)
+
+	c.certsMutex.Lock()
+	entry, ok := c.certs[addr]
+	if !ok {
+		entry = &certEntry{}
+		c.certs[addr] = entry
+	}
+	c.certsMutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.status = inspectCertificate(c.transport(), addr, c.opts.CertWarnDays, c.opts.UserAgent)
+	})
+
+	return entry.status
+}
+
+// inspectCertificate performs a handshake through the client's transport, so
+// its proxy, CA bundles and client certificate are honoured.
+func inspectCertificate(transport http.RoundTripper, addr string, warnDays int, userAgent string) *CertStatus {
+	var state *tls.ConnectionState
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			if err == nil {
+				state = &cs
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "HEAD", "https://"+addr+"/", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil && state == nil {
+		return classifyCertError(addr, err)
+	}
+	if resp != nil {
+		if resp.TLS != nil {
+			state = resp.TLS
+		}
+		resp.Body.Close()
+	}
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	status := &CertStatus{Host: addr, NotAfter: leaf.NotAfter}
+
+	remaining := time.Until(leaf.NotAfter)
+	if remaining < time.Duration(warnDays)*24*time.Hour {
+		status.Problem = CertExpiringSoon
+		status.Detail = fmt.Sprintf("certificate expires in %d days (%s)",
+			int(remaining.Hours()/24), leaf.NotAfter.Format("2006-01-02"))
+	}
+
+	return status
+}
+
+func classifyCertError(addr string, err error) *CertStatus {
+	status := &CertStatus{Host: addr, Detail: err.Error()}
+
+	var invalidErr x509.CertificateInvalidError
+	var hostErr x509.HostnameError
+	var authErr x509.UnknownAuthorityError
+
+	switch {
+	case errors.As(err, &invalidErr):
+		status.NotAfter = invalidErr.Cert.NotAfter
+		switch {
+		case invalidErr.Reason != x509.Expired:
+			status.Problem = CertInvalid
+		case time.Now().Before(invalidErr.Cert.NotBefore):
+			status.Problem = CertNotYetValid
+		default:
+			status.Problem = CertExpired
+		}
+	case errors.As(err, &hostErr):
+		status.Problem = CertHostnameMismatch
+		status.NotAfter = hostErr.Certificate.NotAfter
+	case errors.As(err, &authErr):
+		status.Problem = CertUnknownAuthority
+		if authErr.Cert != nil {
+			status.NotAfter = authErr.Cert.NotAfter
+			if isSelfSigned(authErr.Cert) {
+				status.Problem = CertSelfSigned
+			}
+		}
+	default:
+		// Not a certificate problem (DNS, refused connection, ...); the HTTP
+		// request will report it on its own.
+		return nil
+	}
+
+	return status
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}